@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/Mr-Rafael/gator/internal/alerts"
+	"github.com/Mr-Rafael/gator/internal/database"
+)
+
+// alertTimeout caps the time an alert may take to notify.
+const alertTimeout = 10 * time.Second
+
+const alertUsage = "alert add <pattern> [--notify stdout|exec|webhook] [--target command name or url] | list | delete <id>"
+
+func handlerAlert(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected a subcommand (%v)", alertUsage)
+	}
+	subcommand := cmd.Arguments[0]
+	arguments := cmd.Arguments[1:]
+
+	switch subcommand {
+	case "add":
+		return addAlert(s, arguments, userData)
+	case "list":
+		return listAlerts(s, userData)
+	case "delete":
+		return deleteAlert(s, arguments, userData)
+	}
+	return fmt.Errorf("Error: unknown subcommand '%v' (%v)", subcommand, alertUsage)
+}
+
+func addAlert(s *state, arguments []string, userData database.User) error {
+	if len(arguments) < 1 {
+		return fmt.Errorf("Error: expected 1 argument (pattern), and found 0")
+	}
+	pattern := arguments[0]
+	_, err := alerts.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("alert add", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	notifier := flags.String("notify", alerts.NotifierStdout, "")
+	target := flags.String("target", "", "")
+	err = flags.Parse(arguments[1:])
+	if err != nil {
+		return fmt.Errorf("Error parsing the alert: %v (%v)", err, alertUsage)
+	}
+	_, err = alerts.NewNotifier(*notifier, *target, s.Configuration.AlertCommands, nil)
+	if err != nil {
+		return err
+	}
+
+	creationParams := database.CreateAlertParams {
+		ID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID: userData.ID,
+		Pattern: pattern,
+		Notifier: *notifier,
+		Target: *target,
+	}
+	alertData, err := s.db.CreateAlert(context.Background(), creationParams)
+	if err != nil {
+		return fmt.Errorf("Error creating the alert: %v", err)
+	}
+
+	fmt.Printf("\nAlert %v created: user <%v> will be notified (%v) of new posts matching /%v/.\n", alertData.ID, userData.Name, alertData.Notifier, alertData.Pattern)
+	return nil
+}
+
+func listAlerts(s *state, userData database.User) error {
+	alertList, err := s.db.GetAlertsForUser(context.Background(), userData.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting the alerts: %v", err)
+	}
+
+	fmt.Printf("\nUser <%v> has these alerts:\n", userData.Name)
+	for _, alertData := range alertList {
+		notifier := alertData.Notifier
+		if alertData.Target != "" {
+			notifier = fmt.Sprintf("%v %v", notifier, alertData.Target)
+		}
+		fmt.Printf("\t- %v /%v/ -> %v\n", alertData.ID, alertData.Pattern, notifier)
+	}
+	return nil
+}
+
+func deleteAlert(s *state, arguments []string, userData database.User) error {
+	if len(arguments) < 1 {
+		return fmt.Errorf("Error: expected 1 argument (id), and found %v", len(arguments))
+	}
+	alertID, err := uuid.Parse(arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error parsing the alert id: %v", err)
+	}
+
+	deleteParams := database.DeleteAlertParams {
+		ID: alertID,
+		UserID: userData.ID,
+	}
+	deleted, err := s.db.DeleteAlert(context.Background(), deleteParams)
+	if err != nil {
+		return fmt.Errorf("Error deleting the alert: %v", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("Error: user <%v> has no alert %v", userData.Name, alertID)
+	}
+
+	fmt.Printf("\nAlert %v deleted.\n", alertID)
+	return nil
+}
+
+func handlerAlerts(s *state, cmd command, userData database.User) error {
+	limit := 20
+	if len(cmd.Arguments) >= 1 {
+		var err error
+		limit, err = strconv.Atoi(cmd.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("Expected a numeric parameter for command. %v", err)
+		}
+	}
+
+	getEventsParams := database.GetAlertEventsForUserParams {
+		UserID: userData.ID,
+		Limit: int32(limit),
+	}
+	events, err := s.db.GetAlertEventsForUser(context.Background(), getEventsParams)
+	if err != nil {
+		return fmt.Errorf("Error getting the alert history: %v", err)
+	}
+
+	fmt.Printf("\nLatest alerts for user <%v>:\n", userData.Name)
+	for _, event := range events {
+		fmt.Printf("\n| /%v/ | %v |\n", event.Pattern, event.PostTitle)
+		fmt.Printf("Fired at: %v (%v)\n", event.CreatedAt, event.Notifier)
+		fmt.Printf("Link: %v\n", event.PostUrl)
+		if event.Error.Valid {
+			fmt.Printf("Notification failed: %v\n", event.Error.String)
+		}
+	}
+	return nil
+}
+
+// firedAlert is an alert a new post matched, waiting to be notified.
+type firedAlert struct {
+	alert database.GetAlertsForFeedRow
+	postID uuid.UUID
+	notification alerts.Notification
+}
+
+// checkAlerts matches a newly stored post against the alerts of every user
+// following its feed, except the ones in mutedFor, and returns the ones
+// that fire for sendAlerts.
+func checkAlerts(s *state, feedData database.Feed, post database.Post, mutedFor map[uuid.UUID]bool) ([]firedAlert, error) {
+	alertList, err := s.db.GetAlertsForFeed(context.Background(), feedData.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting the alerts for the feed: %v", err)
+	}
+
+	fired := []firedAlert{}
+	for _, alertData := range alertList {
+		if mutedFor[alertData.UserID] {
+			continue
+		}
+		matcher, err := alerts.Compile(alertData.Pattern)
+		if err != nil {
+			s.logger.Warn("Skipping alert", "alert_id", alertData.ID, "error", err)
+			continue
+		}
+		if !matcher.MatchString(post.Title) && !matcher.MatchString(post.Description.String) {
+			continue
+		}
+
+		notification := alerts.Notification {
+			AlertID: alertData.ID,
+			Pattern: alertData.Pattern,
+			UserName: alertData.UserName,
+			Post: alerts.Post {
+				Title: post.Title,
+				URL: post.Url,
+				Description: post.Description.String,
+				Author: post.Author,
+				FeedName: feedData.Name,
+				FeedURL: feedData.Url,
+			},
+		}
+		if post.PublishedAt.Valid {
+			notification.Post.PublishedAt = &post.PublishedAt.Time
+		}
+		fired = append(fired, firedAlert{
+			alert: alertData,
+			postID: post.ID,
+			notification: notification,
+		})
+	}
+	return fired, nil
+}
+
+// sendAlerts notifies and records the alerts fired by a feed's new posts.
+// Each notification gets at most alertTimeout, so a slow command or
+// webhook only holds up the feed it fired for, and only for so long.
+// Failures are recorded with the alert and logged, but don't stop the
+// scrape.
+func sendAlerts(s *state, logger *slog.Logger, fired []firedAlert) {
+	if len(fired) == 0 {
+		return
+	}
+	client, err := hookClient(s)
+	if err != nil {
+		logger.Error("Error preparing the alert notifiers", "error", err)
+		return
+	}
+
+	for _, firedData := range fired {
+		alertData := firedData.alert
+		notifier, notifyErr := alerts.NewNotifier(alertData.Notifier, alertData.Target, s.Configuration.AlertCommands, client)
+		if notifyErr == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), alertTimeout)
+			notifyErr = notifier.Notify(ctx, firedData.notification)
+			cancel()
+		}
+		if notifyErr != nil {
+			logger.Warn("Alert could not notify", "alert_id", alertData.ID, "error", notifyErr)
+		}
+
+		eventParams := database.CreateAlertEventParams {
+			ID: uuid.New(),
+			CreatedAt: time.Now(),
+			AlertID: alertData.ID,
+			PostID: firedData.postID,
+		}
+		if notifyErr != nil {
+			eventParams.Error = sql.NullString{
+				String: notifyErr.Error(),
+				Valid: true,
+			}
+		}
+		err = s.db.CreateAlertEvent(context.Background(), eventParams)
+		if err != nil {
+			logger.Error("Error recording the alert", "alert_id", alertData.ID, "error", err)
+		}
+	}
+}
@@ -14,15 +14,12 @@ type commands struct {
 }
 
 func (c *commands) run(s *state, cmd command) error {
-	fmt.Printf("\nRunning command [%v] with parameters:\n", cmd.Name)
-	for _, arg := range cmd.Arguments {
-		fmt.Printf(" > %v\n", arg)
-	}
+	s.logger.Debug("Running command", "command", cmd.Name, "arguments", cmd.Arguments)
 
 	if handler, ok := c.ValidCommands[cmd.Name]; ok && handler != nil {
     	err := handler(s, cmd)
 		if err != nil {
-			fmt.Printf("\nError running the command: %v\n", err)
+			s.logger.Error("Error running the command", "command", cmd.Name, "error", err)
 		}
 	} else {
     	return fmt.Errorf("Unknown or unregistered command: '%v'", cmd.Name)
@@ -3,24 +3,270 @@ package main
 import (
 	"fmt"
 	"context"
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/migrations"
 )
 
+const migrateUsage = "migrate up | down [--yes] | status"
+
+const resetUsage = "reset [all | posts | fetch-state | user <name>] [--dry-run] [--yes]"
+
+// resetPlan is what a reset scope would delete: a line per kind of row
+// with its count, and the statements to run inside the transaction.
+type resetPlan struct {
+	description string
+	counts []string
+	run func(q database.Querier) error
+}
+
 func handlerReset(s *state, cmd command) error {
-	err := s.db.ResetUsers(context.Background())
+	err := requireAdmin(s)
+	if err != nil {
+		return err
+	}
+
+	scope := "all"
+	scopeArguments := []string{}
+	for _, argument := range cmd.Arguments {
+		if argument == "--dry-run" || argument == "--yes" {
+			continue
+		}
+		scopeArguments = append(scopeArguments, argument)
+	}
+	if len(scopeArguments) >= 1 {
+		scope = scopeArguments[0]
+	}
+
+	plan, err := planReset(s, scope, scopeArguments)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nReset %v. This will delete:\n", plan.description)
+	for _, count := range plan.counts {
+		fmt.Printf("\t- %v\n", count)
+	}
+	if hasFlag(cmd.Arguments, "--dry-run") {
+		fmt.Println("\nDry run, nothing was deleted.")
+		return nil
+	}
+	if !hasFlag(cmd.Arguments, "--yes") && !confirm("Go ahead?") {
+		fmt.Println("\nNothing was deleted.")
+		return nil
+	}
+
+	err = s.store.InTx(context.Background(), plan.run)
+	if err != nil {
+		return err
+	}
+	fmt.Println("\nReset done.")
+	return nil
+}
+
+func planReset(s *state, scope string, arguments []string) (resetPlan, error) {
+	counts, err := s.db.GetDataCounts(context.Background())
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("Error counting the stored data: %v", err)
+	}
+
+	switch scope {
+	case "all":
+		return resetPlan{
+			description: "all data",
+			counts: []string{
+				fmt.Sprintf("%v users", counts.Users),
+				fmt.Sprintf("%v feeds", counts.Feeds),
+				fmt.Sprintf("%v follows", counts.FeedFollows),
+				fmt.Sprintf("%v posts", counts.Posts),
+				"every webhook, rule, alert, tag and read/starred state",
+			},
+			run: resetAll,
+		}, nil
+	case "posts":
+		return resetPlan{
+			description: "posts only",
+			counts: []string{
+				fmt.Sprintf("%v posts, with their read/starred states, tags and alert events", counts.Posts),
+			},
+			run: func(q database.Querier) error {
+				err := q.ResetPosts(context.Background())
+				if err != nil {
+					return fmt.Errorf("Error clearing the posts table: %v", err)
+				}
+				err = q.ResetPrunedPosts(context.Background())
+				if err != nil {
+					return fmt.Errorf("Error clearing the pruned posts table: %v", err)
+				}
+				return nil
+			},
+		}, nil
+	case "fetch-state":
+		return resetPlan{
+			description: "fetch state only",
+			counts: []string{
+				fmt.Sprintf("the last fetch time of %v feeds, so they are fetched again first", counts.FetchedFeeds),
+			},
+			run: func(q database.Querier) error {
+				err := q.ResetFeedFetchState(context.Background())
+				if err != nil {
+					return fmt.Errorf("Error clearing the fetch state: %v", err)
+				}
+				return nil
+			},
+		}, nil
+	case "user":
+		if len(arguments) < 2 {
+			return resetPlan{}, fmt.Errorf("Error: expected a user name (%v)", resetUsage)
+		}
+		return planUserReset(s, arguments[1])
+	}
+	return resetPlan{}, fmt.Errorf("Error: unknown reset scope '%v' (%v)", scope, resetUsage)
+}
+
+// planUserReset clears a user's follows and everything they configured,
+// keeping the account and the feeds they added.
+func planUserReset(s *state, userName string) (resetPlan, error) {
+	userData, err := s.db.GetUser(context.Background(), userName)
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("Error getting the user: %v", err)
+	}
+	usage, err := s.db.GetUserUsage(context.Background(), userData.ID)
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("Error summarizing the user's data: %v", err)
+	}
+
+	return resetPlan{
+		description: fmt.Sprintf("the data of user <%v>", userData.Name),
+		counts: []string{
+			fmt.Sprintf("%v follows, with their tags", usage.Follows),
+			fmt.Sprintf("%v read/starred post states", usage.PostStates),
+			fmt.Sprintf("%v post tags", usage.PostTags),
+			fmt.Sprintf("%v webhooks", usage.Webhooks),
+			fmt.Sprintf("%v rules", usage.Rules),
+			fmt.Sprintf("%v alerts", usage.Alerts),
+		},
+		run: func(q database.Querier) error {
+			steps := []struct {
+				table string
+				run func(context.Context, uuid.UUID) error
+			}{
+				{"feed follows", q.DeleteFeedFollowsForUser},
+				{"post states", q.DeletePostStatesForUser},
+				{"post tags", q.DeletePostTagsForUser},
+				{"webhooks", q.DeleteWebhooksForUser},
+				{"rules", q.DeleteRulesForUser},
+				{"alerts", q.DeleteAlertsForUser},
+			}
+			for _, step := range steps {
+				err := step.run(context.Background(), userData.ID)
+				if err != nil {
+					return fmt.Errorf("Error clearing the user's %v: %v", step.table, err)
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+func resetAll(q database.Querier) error {
+	err := q.ResetUsers(context.Background())
 	if err != nil {
 		return fmt.Errorf("Error clearing the users table: %v", err)
 	}
-	err = s.db.ResetFeeds(context.Background())
+	err = q.ResetFeeds(context.Background())
 	if err != nil {
 		return fmt.Errorf("Error clearing the feeds table: %v", err)
 	}
-	err = s.db.ResetFeedFollows(context.Background())
+	err = q.ResetFeedFollows(context.Background())
 	if err != nil {
 		return fmt.Errorf("Error clearing the feed follows table: %v", err)
 	}
-	err = s.db.ResetPosts(context.Background())
+	err = q.ResetPosts(context.Background())
 	if err != nil {
 		return fmt.Errorf("Error clearing the posts table: %v", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func handlerMigrate(s *state, cmd command) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected a subcommand (%v)", migrateUsage)
+	}
+	provider, err := migrations.NewProvider(s.store)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.Arguments[0] {
+	case "up":
+		results, err := provider.Up(context.Background())
+		for _, result := range results {
+			fmt.Printf("- Applied %v (%v)\n", result.Source.Path, result.Duration)
+		}
+		if err != nil {
+			return fmt.Errorf("Error applying the migrations: %v", err)
+		}
+		if len(results) == 0 {
+			fmt.Println("\nThe database schema is already up to date.")
+			return nil
+		}
+		fmt.Printf("\nThe database schema is now at version %v.\n", results[len(results)-1].Source.Version)
+		return nil
+	case "down":
+		current, err := provider.GetDBVersion(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error reading the database schema version: %v", err)
+		}
+		if current == 0 {
+			fmt.Println("\nThere are no migrations to roll back.")
+			return nil
+		}
+		fmt.Printf("\nRolling back migration %v may drop tables and columns along with their data.\n", current)
+		if !hasFlag(cmd.Arguments, "--yes") && !confirm("Roll it back?") {
+			fmt.Println("\nNothing was rolled back.")
+			return nil
+		}
+		result, err := provider.Down(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error rolling back the migration: %v", err)
+		}
+		fmt.Printf("- Rolled back %v (%v)\n", result.Source.Path, result.Duration)
+		return nil
+	case "status":
+		statuses, err := provider.Status(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error getting the migration status: %v", err)
+		}
+		fmt.Println("\nMigrations:")
+		for _, status := range statuses {
+			if status.State == goose.StateApplied {
+				fmt.Printf("\t- %v: applied on %v\n", status.Source.Path, status.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("\t- %v: pending\n", status.Source.Path)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("Error: unknown subcommand '%v' (%v)", cmd.Arguments[0], migrateUsage)
+}
+
+// requireAdmin checks that the logged in user is an admin. An install with
+// no users yet has nothing to protect, so it passes.
+func requireAdmin(s *state) error {
+	userCount, err := s.db.CountUsers(context.Background())
+	if err != nil {
+		return fmt.Errorf("Error counting the users: %v", err)
+	}
+	if userCount == 0 {
+		return nil
+	}
+	currentUser, err := getCurrentUserData(s)
+	if err != nil {
+		return fmt.Errorf("Error getting current user info: %v", err)
+	}
+	if !currentUser.IsAdmin {
+		return fmt.Errorf("Error: only admins can run this command")
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"time"
+	"database/sql"
+	"net/mail"
+	"slices"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/digest"
+)
+
+const (
+	firstDigestWindow = 24 * time.Hour
+	maxDigestPosts = 100
+)
+
+func handlerSetEmail(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected 1 argument (email), and found %v", len(cmd.Arguments))
+	}
+	address, err := mail.ParseAddress(cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error parsing the email address: %v", err)
+	}
+
+	updateParams := database.SetUserEmailParams {
+		ID: userData.ID,
+		Email: sql.NullString{
+			String: address.Address,
+			Valid: true,
+		},
+		UpdatedAt: time.Now(),
+	}
+	err = s.db.SetUserEmail(context.Background(), updateParams)
+	if err != nil {
+		return fmt.Errorf("Error storing the email address: %v", err)
+	}
+
+	fmt.Printf("\nDigests for user <%v> will be sent to %v.\n", userData.Name, address.Address)
+	return nil
+}
+
+func handlerDigest(s *state, cmd command) error {
+	var every time.Duration
+	preview := false
+	for i := 0; i < len(cmd.Arguments); i++ {
+		switch cmd.Arguments[i] {
+		case "--preview":
+			preview = true
+		case "--every":
+			if i+1 >= len(cmd.Arguments) {
+				return fmt.Errorf("Error: --every expects a duration")
+			}
+			i++
+			var err error
+			every, err = time.ParseDuration(cmd.Arguments[i])
+			if err != nil {
+				return fmt.Errorf("Error parsing the duration argument received: %v", err)
+			}
+		default:
+			return fmt.Errorf("Error: unknown argument '%v' (expected --preview or --every <duration>)", cmd.Arguments[i])
+		}
+	}
+
+	if preview {
+		return previewDigest(s)
+	}
+	if every == 0 {
+		return sendDigests(s)
+	}
+
+	s.logger.Info("Sending digests", "interval", every.String())
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for ;; <- ticker.C {
+		err := sendDigests(s)
+		if err != nil {
+			s.logger.Error("Error sending digests", "error", err)
+		}
+	}
+}
+
+// previewDigest prints the current user's next digest without sending it.
+func previewDigest(s *state) error {
+	userData, err := getCurrentUserData(s)
+	if err != nil {
+		return fmt.Errorf("Error getting current user info: %v", err)
+	}
+	digestData, _, _, err := buildDigest(s, userData, userData.LastDigestItemID)
+	if err != nil {
+		return err
+	}
+	text, err := digest.Text(digestData)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n%v\n", text)
+	return nil
+}
+
+// sendDigests emails every user with an address the unread posts stored
+// since their last digest. Users with nothing new get no email.
+func sendDigests(s *state) error {
+	recipients, err := s.db.GetDigestRecipients(context.Background())
+	if err != nil {
+		return fmt.Errorf("Error getting the digest recipients: %v", err)
+	}
+
+	for _, userData := range recipients {
+		err = sendDigest(s, userData)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDigest emails a user their unread posts, maxDigestPosts at a time,
+// moving their digest marker up to the last post each email covers.
+func sendDigest(s *state, userData database.User) error {
+	afterItemID := userData.LastDigestItemID
+	for {
+		generatedAt := time.Now()
+		digestData, lastItemID, more, err := buildDigest(s, userData, afterItemID)
+		if err != nil {
+			return err
+		}
+		if len(digestData.Posts) == 0 {
+			fmt.Printf("- No new posts for <%v>\n", userData.Name)
+			return nil
+		}
+
+		message, err := digest.Message(digestData, s.Configuration.SMTP.From, userData.Email.String)
+		if err != nil {
+			return err
+		}
+		err = digest.Send(s.Configuration.SMTP, userData.Email.String, message)
+		if err != nil {
+			s.logger.Error("Error sending the digest", "user", userData.Name, "error", err)
+			return nil
+		}
+
+		afterItemID = sql.NullInt64{Int64: lastItemID, Valid: true}
+		markSentParams := database.MarkDigestSentParams {
+			ID: userData.ID,
+			LastDigestAt: sql.NullTime{
+				Time: generatedAt,
+				Valid: true,
+			},
+			LastDigestItemID: afterItemID,
+		}
+		err = s.db.MarkDigestSent(context.Background(), markSentParams)
+		if err != nil {
+			return fmt.Errorf("Error recording the digest for <%v>: %v", userData.Name, err)
+		}
+		fmt.Printf("- Sent %v posts to <%v>\n", len(digestData.Posts), userData.Name)
+		if !more {
+			return nil
+		}
+	}
+}
+
+// digestStart is when the next digest of a user starts: their last digest,
+// or firstDigestWindow ago for their first one.
+func digestStart(userData database.User) time.Time {
+	if userData.LastDigestAt.Valid {
+		return userData.LastDigestAt.Time
+	}
+	return time.Now().Add(-firstDigestWindow)
+}
+
+// buildDigest gathers the oldest maxDigestPosts unread posts stored after
+// the item afterItemID, or since digestStart when there's no marker yet. Posts are paged by item id alone, as agg's workers can store
+// them out of created_at order. It returns the item id of the last post
+// included, and whether more posts are left for another digest.
+func buildDigest(s *state, userData database.User, afterItemID sql.NullInt64) (digest.Digest, int64, bool, error) {
+	streamParams := database.GetStreamForUserParams {
+		UserID: userData.ID,
+		UnreadOnly: true,
+		AfterItemID: afterItemID,
+		OldestFirst: true,
+		MaxItems: maxDigestPosts + 1,
+	}
+	if !afterItemID.Valid {
+		streamParams.NewerThan = sql.NullTime{
+			Time: digestStart(userData),
+			Valid: true,
+		}
+	}
+	posts, err := s.db.GetStreamForUser(context.Background(), streamParams)
+	if err != nil {
+		return digest.Digest{}, 0, false, fmt.Errorf("Error getting posts for user: %v", err)
+	}
+	more := len(posts) > maxDigestPosts
+	if more {
+		posts = posts[:maxDigestPosts]
+	}
+	var lastItemID int64
+	if len(posts) > 0 {
+		lastItemID = posts[len(posts)-1].ItemID
+	}
+	slices.Reverse(posts)
+
+	digestData := digest.Digest {
+		UserName: userData.Name,
+		Since: digestStart(userData),
+	}
+	for _, post := range posts {
+		digestData.Posts = append(digestData.Posts, digest.Post {
+			Title: post.Title,
+			URL: post.Url,
+			FeedName: post.FeedName,
+			Description: post.Description.String,
+			PublishedAt: post.PublishedAt.Time,
+		})
+	}
+	return digestData, lastItemID, more, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// newSMTPServer accepts every email sent to it, and returns the SMTP
+// config to send through it along with the emails received so far.
+func newSMTPServer(t *testing.T) (config.SMTPConfig, func() []*mail.Message) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	received := []*mail.Message{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				text := textproto.NewConn(conn)
+				text.PrintfLine("220 localhost ready")
+				for {
+					line, err := text.ReadLine()
+					if err != nil {
+						return
+					}
+					switch verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); verb {
+					case "EHLO", "HELO":
+						text.PrintfLine("250 localhost")
+					case "DATA":
+						text.PrintfLine("354 go ahead")
+						message, err := mail.ReadMessage(text.DotReader())
+						if err != nil {
+							t.Errorf("reading the email: %v", err)
+							return
+						}
+						mu.Lock()
+						received = append(received, message)
+						mu.Unlock()
+						text.PrintfLine("250 queued")
+					case "QUIT":
+						text.PrintfLine("221 bye")
+						return
+					default:
+						text.PrintfLine("250 ok")
+					}
+				}
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	smtpConfig := config.SMTPConfig{Host: host, Port: portNumber, From: "gator@example.com"}
+	return smtpConfig, func() []*mail.Message {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*mail.Message{}, received...)
+	}
+}
+
+// setTestEmail gives the user an address to send digests to.
+func setTestEmail(t *testing.T, s *state, userData database.User) {
+	t.Helper()
+	err := s.db.SetUserEmail(context.Background(), database.SetUserEmailParams{
+		ID:        userData.ID,
+		Email:     sql.NullString{String: userData.Name + "@example.com", Valid: true},
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("setting the email: %v", err)
+	}
+}
+
+// subjects decodes the subjects of the emails.
+func subjects(emails []*mail.Message) []string {
+	result := []string{}
+	for _, email := range emails {
+		subject, _ := new(mime.WordDecoder).DecodeHeader(email.Header.Get("Subject"))
+		result = append(result, subject)
+	}
+	return result
+}
+
+func TestDigestsSendEveryPostPastTheCap(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	smtpConfig, received := newSMTPServer(t)
+	s.Configuration.SMTP = smtpConfig
+	setTestEmail(t, s, alice)
+
+	items := []testItem{}
+	for i := 0; i < maxDigestPosts+50; i++ {
+		items = append(items, testItem{
+			title:   fmt.Sprintf("Post %v", i),
+			link:    fmt.Sprintf("http://example.com/%v", i),
+			pubDate: time.Now(),
+		})
+	}
+	server := newFeedServer(t, items...)
+	addTestFeed(t, s, alice, "Busy", server.URL+"/feed.xml")
+	err := scrape(t, s)
+	if err != nil {
+		t.Fatalf("scraping: %v", err)
+	}
+
+	output, err := captureOutput(t, func() error { return sendDigests(s) })
+	if err != nil {
+		t.Fatalf("sending the digests: %v", err)
+	}
+	for _, sent := range []string{"Sent 100 posts", "Sent 50 posts"} {
+		if !strings.Contains(output, sent) {
+			t.Errorf("output = %q, want it to say %q", output, sent)
+		}
+	}
+	emails := received()
+	if len(emails) != 2 {
+		t.Fatalf("sent %v emails, want 2", len(emails))
+	}
+	want := []string{"Gator digest: 100 new posts", "Gator digest: 50 new posts"}
+	if got := subjects(emails); !slices.Equal(got, want) {
+		t.Errorf("sent the subjects %q, want %q", got, want)
+	}
+
+	output, err = captureOutput(t, func() error { return sendDigests(s) })
+	if err != nil {
+		t.Fatalf("sending the digests again: %v", err)
+	}
+	if !strings.Contains(output, "No new posts") || len(received()) != 2 {
+		t.Errorf("the second run sent more emails: %q", output)
+	}
+}
+
+func TestDigestsPageByItemID(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	smtpConfig, received := newSMTPServer(t)
+	s.Configuration.SMTP = smtpConfig
+	setTestEmail(t, s, alice)
+	addTestFeed(t, s, alice, "Busy", "http://example.com/feed.xml")
+	feedData, err := s.db.GetFeedFromURL(context.Background(), "http://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("getting the feed: %v", err)
+	}
+	createPost := func(i int, createdAt time.Time) {
+		t.Helper()
+		_, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
+			ID:        uuid.New(),
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+			Title:     fmt.Sprintf("Post %v", i),
+			Url:       fmt.Sprintf("http://example.com/%v", i),
+			FeedID:    feedData.ID,
+		})
+		if err != nil {
+			t.Fatalf("creating a post: %v", err)
+		}
+	}
+
+	// The post past the cap shares its created_at with the ones before
+	// it, and concurrent workers can store a post after a newer one.
+	stored := time.Now().Add(-time.Hour)
+	for i := 0; i <= maxDigestPosts; i++ {
+		createPost(i, stored)
+	}
+	createPost(maxDigestPosts+1, stored.Add(-time.Minute))
+	err = sendDigests(s)
+	if err != nil {
+		t.Fatalf("sending the digests: %v", err)
+	}
+	createPost(maxDigestPosts+2, time.Now())
+	err = sendDigests(s)
+	if err != nil {
+		t.Fatalf("sending the digests again: %v", err)
+	}
+
+	want := []string{"Gator digest: 100 new posts", "Gator digest: 2 new posts", "Gator digest: 1 new post"}
+	if got := subjects(received()); !slices.Equal(got, want) {
+		t.Errorf("sent the subjects %q, want %q", got, want)
+	}
+}
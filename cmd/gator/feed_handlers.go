@@ -2,35 +2,103 @@ package main
 
 import (
 	"fmt"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
 	"context"
 	"time"
 	"strconv"
+	"strings"
+	"sync"
 	"database/sql"
+	"encoding/json"
 	"github.com/google/uuid"
 	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/live"
+	"github.com/Mr-Rafael/gator/internal/metrics"
+	"github.com/Mr-Rafael/gator/internal/retention"
 	"github.com/Mr-Rafael/gator/internal/rss"
+	"github.com/Mr-Rafael/gator/internal/rules"
+	"github.com/Mr-Rafael/gator/internal/schedule"
 )
 
+const aggUsage = "agg <time between reqs> [--workers n]"
+
 func handlerAgg(s *state, cmd command) error {
 	if len(cmd.Arguments) < 1 {
-		return fmt.Errorf("Error: expected 1 argument (time between reqs), and found %v", len(cmd.Arguments))
+		return fmt.Errorf("Error: expected 1 argument (time between reqs), and found %v (%v)", len(cmd.Arguments), aggUsage)
 	}
 	duration, err := time.ParseDuration(cmd.Arguments[0])
 	if err != nil {
 		return fmt.Errorf("Error parsing the duration argument received: %v", err)
 	}
-	fmt.Printf("\nCollecting feeds every %v\n", duration)
+	flags := flag.NewFlagSet("agg", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	workers := flags.Int("workers", 1, "")
+	err = flags.Parse(cmd.Arguments[1:])
+	if err != nil {
+		return fmt.Errorf("Error parsing the arguments: %v (%v)", err, aggUsage)
+	}
+	if *workers < 1 {
+		return fmt.Errorf("Error: --workers must be at least 1")
+	}
+	s.fetcher, err = rss.NewFetcher(s.Configuration.Fetch)
+	if err != nil {
+		return err
+	}
+	_, err = retention.FromConfig(s.Configuration.Retention)
+	if err != nil {
+		return err
+	}
+	_, err = schedule.FromConfig(s.Configuration.Schedule)
+	if err != nil {
+		return err
+	}
+	pruneInterval, err := retention.Interval(s.Configuration.Retention)
+	if err != nil {
+		return err
+	}
+	overdueAfter, err := metrics.OverdueAfter(s.Configuration.Metrics)
+	if err != nil {
+		return err
+	}
+	if s.Configuration.Metrics.Listen != "" {
+		err = serveMetrics(s, s.Configuration.Metrics.Listen)
+		if err != nil {
+			return err
+		}
+	}
+	s.logger.Info("Collecting feeds", "interval", duration.String(), "workers", *workers)
 
 	ticker := time.NewTicker(duration)
 	defer ticker.Stop()
+	lastPrune := time.Time{}
 	for ;; <- ticker.C {
-		fmt.Println("\nIt's scrapin' time!")
-		err = scrapeFeeds(s)
+		err = scrapeWorkers(s, *workers)
 		if err != nil {
 			return fmt.Errorf("Error scraping feeds: %v", err)
 		}
+		overdue, err := s.db.CountOverdueFeeds(context.Background(), time.Now().Add(-overdueAfter))
+		if err != nil {
+			s.logger.Error("Error counting the overdue feeds", "error", err)
+		} else {
+			metrics.FeedsOverdue.Set(float64(overdue))
+		}
+		err = deliverWebhooks(s, time.Now())
+		if err != nil {
+			s.logger.Error("Error delivering webhooks", "error", err)
+		}
+		if time.Since(lastPrune) >= pruneInterval {
+			lastPrune = time.Now()
+			result, err := prunePosts(s, false)
+			if err != nil {
+				s.logger.Error("Error pruning posts", "error", err)
+			} else if result.posts() > 0 {
+				s.logger.Info("Pruned posts", "posts", result.posts(), "feeds", result.feeds)
+			}
+		}
 	}
-	return nil
 }
 
 func handlerAddFeed(s *state, cmd command, userData database.User) error {
@@ -39,12 +107,16 @@ func handlerAddFeed(s *state, cmd command, userData database.User) error {
 	}
 	feedName := cmd.Arguments[0]
 	feedURL := cmd.Arguments[1]
+	_, err := rss.ParseFeedURL(feedURL)
+	if err != nil {
+		return fmt.Errorf("Error: %v", err)
+	}
 
 	feedCreationParams := database.CreateFeedParams {	
 		ID: uuid.New(),
 		Name: feedName,
 		Url: feedURL,
-		UserID: userData.ID,
+		UserID: uuid.NullUUID{UUID: userData.ID, Valid: true},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -102,12 +174,12 @@ func handlerFollow(s *state, cmd command, userData database.User) error {
 		FeedID: feedData.ID,
 	}
 
-	followData, err := s.db.CreateFeedFollow(context.Background(), creationParams)
+	_, err = s.db.CreateFeedFollow(context.Background(), creationParams)
 	if err != nil {
 		return fmt.Errorf("Error creating follow in the database: %v", err)
 	}
 
-	fmt.Printf("\nUser <%v> is now following '%v'.\n", followData.Name, followData.Name_2)
+	fmt.Printf("\nUser <%v> is now following '%v'.\n", userData.Name, feedData.Name)
 	return nil
 }
 
@@ -116,9 +188,38 @@ func handlerFollowing(s *state, cmd command, userData database.User) error {
 	if err != nil {
 		return fmt.Errorf("\nError fetching follow data: %v\n", err)
 	}
+	feedTags, err := s.db.GetFeedFollowTagsForUser(context.Background(), userData.ID)
+	if err != nil {
+		return fmt.Errorf("\nError fetching the feed tags: %v\n", err)
+	}
+
 	fmt.Printf("\nUser <%v> is following these feeds:\n", userData.Name)
+	tagged := map[uuid.UUID]bool{}
+	currentTag := ""
+	for _, feedTag := range feedTags {
+		if feedTag.Tag != currentTag {
+			currentTag = feedTag.Tag
+			fmt.Printf("\n[%v]\n", currentTag)
+		}
+		tagged[feedTag.FeedID] = true
+		for _, follow := range feedFollows {
+			if follow.FeedID == feedTag.FeedID {
+				fmt.Printf("\t- %v\n", follow.Name)
+			}
+		}
+	}
+
+	untagged := []string{}
 	for _, follow := range feedFollows {
-		fmt.Printf("\t- %v\n", follow.Name)
+		if !tagged[follow.FeedID] {
+			untagged = append(untagged, follow.Name)
+		}
+	}
+	if len(untagged) > 0 && len(feedTags) > 0 {
+		fmt.Printf("\n[untagged]\n")
+	}
+	for _, name := range untagged {
+		fmt.Printf("\t- %v\n", name)
 	}
 	return nil
 }
@@ -148,23 +249,63 @@ func handlerUnfollow(s *state, cmd command, userData database.User) error {
 	return nil
 }
 
+func handlerRenameFeed(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 2 {
+		return fmt.Errorf("Error: expected 2 arguments (url, name), and found %v", len(cmd.Arguments))
+	}
+	feedURL := cmd.Arguments[0]
+	title := strings.TrimSpace(cmd.Arguments[1])
+
+	feedData, err := s.db.GetFeedFromURL(context.Background(), feedURL)
+	if err != nil {
+		return fmt.Errorf("Error getting the feed data: %v", err)
+	}
+
+	renameParams := database.SetFeedFollowTitleParams {
+		UserID: userData.ID,
+		FeedID: feedData.ID,
+		Title: optionalString(title),
+		UpdatedAt: time.Now(),
+	}
+	renamed, err := s.db.SetFeedFollowTitle(context.Background(), renameParams)
+	if err != nil {
+		return fmt.Errorf("Error renaming the feed: %v", err)
+	}
+	if renamed == 0 {
+		return fmt.Errorf("Error: user <%v> is not following '%v'", userData.Name, feedURL)
+	}
+
+	if title == "" {
+		fmt.Printf("\nUser <%v> now sees feed '%v' by its original name.\n", userData.Name, feedData.Name)
+		return nil
+	}
+	fmt.Printf("\nUser <%v> now sees feed '%v' as '%v'.\n", userData.Name, feedData.Name, title)
+	return nil
+}
+
 func handlerBrowse(s *state, cmd command, userData database.User) error {
 	var err error
 
 	limit := 2
-	if len(cmd.Arguments) >= 1 {
-		limit, err = strconv.Atoi(cmd.Arguments[0])
+	tag := sql.NullString{}
+	for i := 0; i < len(cmd.Arguments); i++ {
+		if cmd.Arguments[i] == "--tag" {
+			if i+1 >= len(cmd.Arguments) {
+				return fmt.Errorf("Error: --tag expects a tag name")
+			}
+			tag = sql.NullString{String: cmd.Arguments[i+1], Valid: true}
+			i++
+			continue
+		}
+		limit, err = strconv.Atoi(cmd.Arguments[i])
 		if err != nil {
 			return fmt.Errorf("Expected a numeric parameter for command. %v", err)
 		}
 	}
-	currentUser, err := getCurrentUserData(s)
-	if err != nil {
-		return fmt.Errorf("Error getting current user info: %v", err)
-	}
 
 	getPostsParams := database.GetPostsForUserParams{
-		UserID: currentUser.ID,
+		UserID: userData.ID,
+		Tag: tag,
 		Limit: int32(limit),
 	}
 	posts, err := s.db.GetPostsForUser(context.Background(), getPostsParams)
@@ -175,46 +316,188 @@ func handlerBrowse(s *state, cmd command, userData database.User) error {
 	fmt.Println("Got the following posts:")
 	for _, post := range posts {
 		printPost(post)
+		getTagsParams := database.GetPostTagsParams{
+			UserID: userData.ID,
+			PostID: post.ID,
+		}
+		tags, err := s.db.GetPostTags(context.Background(), getTagsParams)
+		if err != nil {
+			return fmt.Errorf("Error getting the post tags: %v", err)
+		}
+		if len(tags) > 0 {
+			fmt.Printf("Tags: %v\n", strings.Join(tags, ", "))
+		}
 	}
 	return nil
 }
 
-func printPost(p database.Post) {
+func printPost(p database.GetPostsForUserRow) {
 	fmt.Printf("\n| %v |\n", p.Title)
 	fmt.Printf("----------\n")
-	fmt.Printf("Published on: %v\n\n", p.PublishedAt)
+	fmt.Printf("From: %v\n", p.FeedName)
+	fmt.Printf("Published on: %v\n", p.PublishedAt)
+	if p.Author != "" {
+		fmt.Printf("By: %v\n", p.Author)
+	}
+	fmt.Println()
 	fmt.Printf("%v\n\n", p.Description)
 	fmt.Printf("Link: %v\n", p.Url)
 }
 
+// claimFeed makes picking the next feed and marking it fetched one step,
+// so concurrent workers never scrape the same feed.
+var claimFeed sync.Mutex
+
+// scrapeWorkers scrapes up to workers due feeds at once, sharing the host
+// limits of the fetcher. Feeds failing to fetch don't stop the others, so
+// only the database errors of the workers are returned.
+func scrapeWorkers(s *state, workers int) error {
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Go(func() {
+			errs[i] = scrapeFeeds(s)
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
 func scrapeFeeds(s *state) error {
-	feedData, err := s.db.GetNextFeedToFetch(context.Background())
+	policy, err := schedule.FromConfig(s.Configuration.Schedule)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	feedData, err := claimNextFeed(s, policy, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.logger.Debug("No feeds are due")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logger := feedLogger(s, feedData)
+	logger.Info("It's scrapin' time!")
+	fetch := database.CreateFeedFetchParams {
+		ID: uuid.New(),
+		FeedID: feedData.ID,
+		StartedAt: time.Now(),
+	}
+	hints, err := scrapeFeed(s, logger, feedData, &fetch)
+	recordFetch(s, logger, fetch, err)
+	var failedFetch fetchError
+	if errors.As(err, &failedFetch) {
+		// The feed is retried after its usual interval, and the other
+		// feeds go on being scraped.
+		logger.Error("Error fetching the feed", "error", failedFetch.err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	publishTimesParams := database.GetRecentPublishTimesParams {
+		FeedID: feedData.ID,
+		Limit: schedule.CadencePosts,
+	}
+	published, err := s.db.GetRecentPublishTimes(context.Background(), publishTimesParams)
+	if err != nil {
+		return fmt.Errorf("Error getting the publish times of the feed's posts: %v", err)
+	}
+	hints.Cadence = schedule.Cadence(published, now)
+	plan := policy.Plan(feedData, hints, now)
+	scheduleParams := database.ScheduleFeedFetchParams {
+		ID: feedData.ID,
+		NextFetchAt: plan.Next,
+		FetchIntervalSeconds: int64(plan.Interval / time.Second),
+		FetchIntervalSource: plan.Source,
+	}
+	err = s.db.ScheduleFeedFetch(context.Background(), scheduleParams)
 	if err != nil {
-		return fmt.Errorf("Error getting next feed to update from the database: %v", err)
+		return fmt.Errorf("Error scheduling the next fetch of the feed: %v", err)
+	}
+
+	logger.Info("Done scrapin'!", "items", fetch.Items, "new_posts", fetch.NewPosts, "next_fetch_at", plan.Next, "interval", plan.Interval.String(), "interval_source", plan.Source)
+
+	return nil
+}
+
+// claimNextFeed returns the feed due the longest, marked as fetched at
+// now. Until the fetch succeeds, the feed is retried after its usual
+// interval, so a failing feed doesn't block the others.
+func claimNextFeed(s *state, policy schedule.Policy, now time.Time) (database.Feed, error) {
+	claimFeed.Lock()
+	defer claimFeed.Unlock()
+	feedData, err := s.db.GetNextFeedToFetch(context.Background(), now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, err
+	}
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("Error getting next feed to update from the database: %v", err)
 	}
 
 	markFetchedParams := database.MarkFeedFetchedParams {
 		ID: feedData.ID,
 		LastFetchedAt: sql.NullTime{
-			Time:  time.Now(),
+			Time:  now,
+			Valid: true,
+		},
+		NextFetchAt: sql.NullTime{
+			Time: policy.Plan(feedData, schedule.Hints{}, now).Next,
 			Valid: true,
 		},
 	}
-
 	err = s.db.MarkFeedFetched(context.Background(), markFetchedParams)
 	if err != nil {
-		return fmt.Errorf("Error marking the feed as updated in the database: %v", err)
-	}	
+		return database.Feed{}, fmt.Errorf("Error marking the feed as updated in the database: %v", err)
+	}
+	return feedData, nil
+}
+
+// feedLogger returns the logger for work on one feed, which tags every
+// record with the feed.
+func feedLogger(s *state, feedData database.Feed) *slog.Logger {
+	return s.logger.With("feed_id", feedData.ID, "feed_url", feedData.Url)
+}
+
+// fetchError is a failure to fetch or parse a feed. It's the feed's own
+// problem, so it's recorded in the feed's fetch log rather than stopping
+// agg.
+type fetchError struct {
+	err error
+}
+
+func (e fetchError) Error() string {
+	return fmt.Sprintf("Error fetching the feed: %v", e.err)
+}
 
-	feedContent, err := rss.FetchFeed(context.Background(), feedData.Url)
+func (e fetchError) Unwrap() error {
+	return e.err
+}
+
+// scrapeFeed fetches the feed and stores its new posts, filling in the
+// fetch record as it goes. It returns what the feed says about how often
+// to fetch it.
+func scrapeFeed(s *state, logger *slog.Logger, feedData database.Feed, fetch *database.CreateFeedFetchParams) (schedule.Hints, error) {
+	feedContent, info, err := s.fetcher.FetchFeed(context.Background(), logger, feedData.Url)
+	fetch.Bytes = info.Bytes
+	if info.StatusCode != 0 {
+		fetch.StatusCode = sql.NullInt32{Int32: int32(info.StatusCode), Valid: true}
+	}
 	if err != nil {
-		return fmt.Errorf("Error fetching the feed '%v' from URL: %v", feedData.Name, err)
+		return schedule.Hints{}, fetchError{err}
 	}
+	fetch.Items = int32(len(feedContent.Channel.Item))
+	hints := schedule.FromFeed(feedContent, info.Header, time.Now())
 
-	fmt.Println("Storing the posts on the Database: ")
+	// Alerts are sent once the posts are stored, including the ones stored
+	// before a failure.
+	fired := []firedAlert{}
+	defer func() { sendAlerts(s, logger, fired) }()
 
 	for _, feedItem := range feedContent.Channel.Item {
-		fmt.Printf("- Storing '%v'\n", feedItem.Title)
 		savePostParams := database.CreatePostParams {
 			ID: uuid.New(),
 			CreatedAt: time.Now(),
@@ -227,18 +510,95 @@ func scrapeFeeds(s *state) error {
     		},
 			PublishedAt: parseNullableTime(feedItem.PubDate),
 			FeedID:	feedData.ID,
+			Author: feedItem.ItemAuthor(),
+			Categories: rules.JoinCategories(feedItem.Categories),
+		}
+		post, create_error := s.db.CreatePost(context.Background(), savePostParams)
+		if errors.Is(create_error, sql.ErrNoRows) {
+			// The post was stored by an earlier scrape.
+			continue
 		}
-		create_error := s.db.CreatePost(context.Background(), savePostParams)
 		if create_error != nil {
-			return fmt.Errorf("Error storing the post on the database: %v", create_error)
+			return schedule.Hints{}, fmt.Errorf("Error storing the post on the database: %v", create_error)
+		}
+		fetch.NewPosts++
+		logger.Debug("Stored new post", "post_id", post.ID, "post_url", post.Url)
+		mutedFor, err := applyRules(s, post)
+		if err != nil {
+			return schedule.Hints{}, err
+		}
+		// Users who muted the post get no alerts or webhooks for it, and
+		// the live stream leaves it out for them.
+		postAlerts, err := checkAlerts(s, feedData, post, mutedFor)
+		if err != nil {
+			return schedule.Hints{}, err
+		}
+		fired = append(fired, postAlerts...)
+		notifyNewPost(s, post)
+		err = enqueueWebhooks(s, post, mutedFor)
+		if err != nil {
+			return schedule.Hints{}, err
 		}
 	}
+	err = expirePrunedPosts(s, logger, feedData, feedContent)
+	if err != nil {
+		return schedule.Hints{}, err
+	}
+	return hints, nil
+}
 
-	fmt.Println("\nDone scrapin'!\n")
+// expirePrunedPosts forgets the pruned posts the feed no longer lists,
+// since they can't come back. An empty feed is more likely a glitch than
+// a feed that dropped every post, so it leaves them alone.
+func expirePrunedPosts(s *state, logger *slog.Logger, feedData database.Feed, feedContent *rss.RSSFeed) error {
+	if len(feedContent.Channel.Item) == 0 {
+		return nil
+	}
+	listed := map[string]bool{}
+	for _, feedItem := range feedContent.Channel.Item {
+		listed[feedItem.Link] = true
+	}
 
+	prunedURLs, err := s.db.GetPrunedPostURLsForFeed(context.Background(), feedData.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting the pruned posts of the feed: %v", err)
+	}
+	expired := 0
+	for _, prunedURL := range prunedURLs {
+		if listed[prunedURL] {
+			continue
+		}
+		err = s.db.DeletePrunedPost(context.Background(), prunedURL)
+		if err != nil {
+			return fmt.Errorf("Error forgetting the pruned post '%v': %v", prunedURL, err)
+		}
+		expired++
+	}
+	if expired > 0 {
+		logger.Debug("Forgot pruned posts the feed no longer lists", "count", expired)
+	}
 	return nil
 }
 
+func notifyNewPost(s *state, post database.Post) {
+	payload, err := json.Marshal(live.NewPost{
+		ItemID: post.ItemID,
+		FeedID: post.FeedID,
+	})
+	if err != nil {
+		s.logger.Error("Error encoding the new post notification", "post_id", post.ID, "error", err)
+		return
+	}
+	notifyParams := database.NotifyNewPostParams{
+		Channel: live.Channel,
+		Payload: string(payload),
+	}
+	err = s.db.NotifyNewPost(context.Background(), notifyParams)
+	if err != nil {
+		s.logger.Error("Error sending the new post notification", "post_id", post.ID, "error", err)
+	}
+}
+
 func parseNullableTime(input string) sql.NullTime {
 	layouts := []string{
         time.RFC1123Z,
@@ -258,3 +618,174 @@ func parseNullableTime(input string) sql.NullTime {
     return sql.NullTime{Valid: false}
 
 }
+
+const editFeedUsage = "editfeed <url> [--name name] [--url new-url] [--max-age duration|default] [--max-posts count|default] [--refresh duration|default]"
+
+func handlerEditFeed(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected a feed url (%v)", editFeedUsage)
+	}
+	feedData, err := s.db.GetFeedFromURL(context.Background(), cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error getting the feed data: %v", err)
+	}
+	err = checkFeedOwner(s, feedData, userData)
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("editfeed", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	name := flags.String("name", feedData.Name, "")
+	newURL := flags.String("url", feedData.Url, "")
+	maxAge := flags.String("max-age", "", "")
+	maxPosts := flags.String("max-posts", "", "")
+	refresh := flags.String("refresh", "", "")
+	err = flags.Parse(cmd.Arguments[1:])
+	if err != nil {
+		return fmt.Errorf("Error parsing the arguments: %v (%v)", err, editFeedUsage)
+	}
+	if *name == "" || *newURL == "" {
+		return fmt.Errorf("Error: the feed name and url can't be empty")
+	}
+	if *newURL != feedData.Url {
+		_, err = rss.ParseFeedURL(*newURL)
+		if err != nil {
+			return fmt.Errorf("Error: %v", err)
+		}
+	}
+
+	updateParams := database.UpdateFeedParams {
+		ID: feedData.ID,
+		Name: *name,
+		Url: *newURL,
+		RetentionMaxAgeSeconds: feedData.RetentionMaxAgeSeconds,
+		RetentionMaxPosts: feedData.RetentionMaxPosts,
+		RefreshIntervalSeconds: feedData.RefreshIntervalSeconds,
+		NextFetchAt: feedData.NextFetchAt,
+		UpdatedAt: time.Now(),
+	}
+	if *maxAge == "default" {
+		updateParams.RetentionMaxAgeSeconds = sql.NullInt64{}
+	} else if *maxAge != "" {
+		age, err := time.ParseDuration(*maxAge)
+		if err != nil || age < 0 {
+			return fmt.Errorf("Error: --max-age expects a duration such as 720h, 0 or 'default'")
+		}
+		updateParams.RetentionMaxAgeSeconds = sql.NullInt64{Int64: int64(age / time.Second), Valid: true}
+	}
+	if *maxPosts == "default" {
+		updateParams.RetentionMaxPosts = sql.NullInt32{}
+	} else if *maxPosts != "" {
+		count, err := strconv.Atoi(*maxPosts)
+		if err != nil || count < 0 {
+			return fmt.Errorf("Error: --max-posts expects a number of posts, 0 or 'default'")
+		}
+		updateParams.RetentionMaxPosts = sql.NullInt32{Int32: int32(count), Valid: true}
+	}
+	if *refresh == "default" {
+		updateParams.RefreshIntervalSeconds = sql.NullInt64{}
+	} else if *refresh != "" {
+		interval, err := time.ParseDuration(*refresh)
+		if err != nil || interval < time.Second {
+			return fmt.Errorf("Error: --refresh expects a duration such as 30m, or 'default'")
+		}
+		updateParams.RefreshIntervalSeconds = sql.NullInt64{Int64: int64(interval / time.Second), Valid: true}
+	}
+	// A new url or refresh interval takes effect with a fetch right away.
+	if updateParams.Url != feedData.Url || updateParams.RefreshIntervalSeconds != feedData.RefreshIntervalSeconds {
+		updateParams.NextFetchAt = sql.NullTime{}
+	}
+	feedData, err = s.db.UpdateFeed(context.Background(), updateParams)
+	if err != nil {
+		return fmt.Errorf("Error updating the feed: %v", err)
+	}
+	printStruct("The feed was successfully updated.", feedData)
+	return nil
+}
+
+func handlerDeleteFeed(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected a feed url (deletefeed <url> [--yes])")
+	}
+	feedData, err := s.db.GetFeedFromURL(context.Background(), cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error getting the feed data: %v", err)
+	}
+	err = checkFeedOwner(s, feedData, userData)
+	if err != nil {
+		return err
+	}
+
+	usage, err := s.db.GetFeedUsage(context.Background(), feedData.ID)
+	if err != nil {
+		return fmt.Errorf("Error counting the feed's followers and posts: %v", err)
+	}
+	fmt.Printf("\nFeed '%v' has %v followers and %v stored posts, which will be deleted with it.\n", feedData.Name, usage.Followers, usage.Posts)
+	if !hasFlag(cmd.Arguments, "--yes") && !confirm("Delete it for everyone?") {
+		fmt.Println("\nNothing was deleted.")
+		return nil
+	}
+
+	err = s.db.DeleteFeed(context.Background(), feedData.ID)
+	if err != nil {
+		return fmt.Errorf("Error deleting the feed: %v", err)
+	}
+	fmt.Printf("\nFeed '%v' was deleted.\n", feedData.Name)
+	return nil
+}
+
+func handlerTransferFeed(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 2 {
+		return fmt.Errorf("Error: expected 2 arguments (url, user), and found %v", len(cmd.Arguments))
+	}
+	feedData, err := s.db.GetFeedFromURL(context.Background(), cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error getting the feed data: %v", err)
+	}
+	err = checkFeedOwner(s, feedData, userData)
+	if err != nil {
+		return err
+	}
+	newOwner, err := s.db.GetUser(context.Background(), cmd.Arguments[1])
+	if err != nil {
+		return fmt.Errorf("Error getting the user: %v", err)
+	}
+
+	ownerParams := database.SetFeedOwnerParams {
+		ID: feedData.ID,
+		UserID: uuid.NullUUID{UUID: newOwner.ID, Valid: true},
+		UpdatedAt: time.Now(),
+	}
+	err = s.db.SetFeedOwner(context.Background(), ownerParams)
+	if err != nil {
+		return fmt.Errorf("Error transferring the feed: %v", err)
+	}
+	fmt.Printf("\nFeed '%v' now belongs to user <%v>.\n", feedData.Name, newOwner.Name)
+	return nil
+}
+
+// checkFeedOwner allows managing a feed to admins and the user who owns
+// it. A feed whose owner was deleted can be managed by any of its
+// followers, so it can be handed to someone new.
+func checkFeedOwner(s *state, feedData database.Feed, userData database.User) error {
+	if userData.IsAdmin {
+		return nil
+	}
+	if feedData.UserID.Valid {
+		if feedData.UserID.UUID == userData.ID {
+			return nil
+		}
+		return fmt.Errorf("Error: feed '%v' belongs to another user", feedData.Name)
+	}
+
+	followParams := database.GetFeedFollowParams {
+		UserID: userData.ID,
+		FeedID: feedData.ID,
+	}
+	_, err := s.db.GetFeedFollow(context.Background(), followParams)
+	if err != nil {
+		return fmt.Errorf("Error: feed '%v' has no owner and user <%v> is not following it", feedData.Name, userData.Name)
+	}
+	return nil
+}
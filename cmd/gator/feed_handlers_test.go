@@ -0,0 +1,648 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/metrics"
+	"github.com/Mr-Rafael/gator/internal/rss"
+	"github.com/Mr-Rafael/gator/internal/storage/memory"
+	"github.com/google/uuid"
+)
+
+type testItem struct {
+	title   string
+	link    string
+	pubDate time.Time
+}
+
+// newTestState returns a state backed by an in-memory store, with a user
+// to run the logged in handlers as.
+func newTestState(t *testing.T, userName string) (*state, database.User) {
+	t.Helper()
+	s := &state{
+		logger:  slog.New(slog.DiscardHandler),
+		db:      memory.New(),
+		fetcher: newTestFetcher(t, config.FetchConfig{}),
+		Configuration: &config.Config{
+			// The webhooks are received on localhost too.
+			Fetch: config.FetchConfig{AllowHosts: []string{"127.0.0.1"}},
+		},
+	}
+	return s, createTestUser(t, s, userName)
+}
+
+// newTestFetcher returns a fetcher configured by conf for the test feeds,
+// which are all served from localhost: it may connect to 127.0.0.1, and
+// unless conf says otherwise, it doesn't wait between fetches.
+func newTestFetcher(t *testing.T, conf config.FetchConfig) *rss.Fetcher {
+	t.Helper()
+	if conf.PerHost.MinDelay == "" {
+		conf.PerHost.MinDelay = "0s"
+	}
+	conf.AllowHosts = append(conf.AllowHosts, "127.0.0.1")
+	fetcher, err := rss.NewFetcher(conf)
+	if err != nil {
+		t.Fatalf("creating the fetcher: %v", err)
+	}
+	return fetcher
+}
+
+func createTestUser(t *testing.T, s *state, name string) database.User {
+	t.Helper()
+	userData, err := s.db.CreateUser(context.Background(), database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+	})
+	if err != nil {
+		t.Fatalf("creating user %v: %v", name, err)
+	}
+	return userData
+}
+
+// newFeedServer serves an RSS feed with the given items at /feed.xml.
+func newFeedServer(t *testing.T, items ...testItem) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		writeFeed(w, items)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeFeed(w http.ResponseWriter, items []testItem) {
+	w.Header().Set("Content-Type", "application/rss+xml")
+	fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Test feed</title>`)
+	for _, item := range items {
+		fmt.Fprintf(w, "<item><title>%v</title><link>%v</link><description>About %v</description><pubDate>%v</pubDate></item>",
+			item.title, item.link, item.title, item.pubDate.Format(time.RFC1123Z))
+	}
+	fmt.Fprint(w, "</channel></rss>")
+}
+
+// captureOutput runs fn and returns what it printed to stdout.
+func captureOutput(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("creating pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	output := make(chan string)
+	go func() {
+		captured, _ := io.ReadAll(reader)
+		output <- string(captured)
+	}()
+
+	fnErr := fn()
+	os.Stdout = stdout
+	writer.Close()
+	return <-output, fnErr
+}
+
+// addTestFeed adds a feed as userData, failing the test if it can't.
+func addTestFeed(t *testing.T, s *state, userData database.User, name, feedURL string) {
+	t.Helper()
+	_, err := run(t, s, userData, handlerAddFeed, name, feedURL)
+	if err != nil {
+		t.Fatalf("addfeed %v: %v", feedURL, err)
+	}
+}
+
+// scrape runs one scrape, discarding what it prints.
+func scrape(t *testing.T, s *state) error {
+	t.Helper()
+	_, err := captureOutput(t, func() error { return scrapeFeeds(s) })
+	return err
+}
+
+// lastFetch returns the latest entry of the feed's fetch log.
+func lastFetch(t *testing.T, s *state, feedURL string) database.FeedFetch {
+	t.Helper()
+	feedData, err := s.db.GetFeedFromURL(context.Background(), feedURL)
+	if err != nil {
+		t.Fatalf("getting the feed %v: %v", feedURL, err)
+	}
+	fetches, err := s.db.GetFeedFetches(context.Background(), database.GetFeedFetchesParams{FeedID: feedData.ID, Limit: 1})
+	if err != nil {
+		t.Fatalf("getting the fetch log: %v", err)
+	}
+	if len(fetches) == 0 {
+		t.Fatalf("the feed %v has no fetches", feedURL)
+	}
+	return fetches[0]
+}
+
+func run(t *testing.T, s *state, userData database.User, handler func(*state, command, database.User) error, arguments ...string) (string, error) {
+	t.Helper()
+	return captureOutput(t, func() error {
+		return handler(s, command{Arguments: arguments}, userData)
+	})
+}
+
+func TestAddFeedCreatesAndFollowsFeed(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+
+	output, err := run(t, s, alice, handlerAddFeed, "News", "http://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("addfeed: %v", err)
+	}
+	if !strings.Contains(output, "User <alice> is now following 'News'.") {
+		t.Errorf("unexpected output:\n%v", output)
+	}
+
+	feedData, err := s.db.GetFeedFromURL(context.Background(), "http://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("getting the feed: %v", err)
+	}
+	if feedData.Name != "News" || feedData.UserID.UUID != alice.ID {
+		t.Errorf("feed = %+v, want name News owned by alice", feedData)
+	}
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatalf("getting the follows: %v", err)
+	}
+	if len(follows) != 1 || follows[0].FeedID != feedData.ID {
+		t.Errorf("follows = %+v, want the new feed only", follows)
+	}
+}
+
+func TestAddFeedRequiresNameAndURL(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+
+	_, err := run(t, s, alice, handlerAddFeed, "News")
+	if err == nil {
+		t.Fatal("addfeed with a single argument succeeded")
+	}
+}
+
+func TestAddFeedRejectsNonHTTPURL(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+
+	for _, feedURL := range []string{"file:///etc/passwd", "gopher://example.com/feed", "http:///feed.xml"} {
+		_, err := run(t, s, alice, handlerAddFeed, "News", feedURL)
+		if err == nil {
+			t.Errorf("addfeed with the url %v succeeded", feedURL)
+		}
+	}
+	feeds, _ := s.db.GetFeeds(context.Background())
+	if len(feeds) != 0 {
+		t.Errorf("found feeds %+v after the failed addfeeds", feeds)
+	}
+}
+
+func TestAddFeedRejectsKnownURL(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	bob := createTestUser(t, s, "bob")
+
+	addTestFeed(t, s, alice, "News", "http://example.com/feed.xml")
+	_, err := run(t, s, bob, handlerAddFeed, "Same news", "http://example.com/feed.xml")
+	if err == nil {
+		t.Fatal("adding a feed url twice succeeded")
+	}
+	follows, _ := s.db.GetFeedFollowsForUser(context.Background(), bob.ID)
+	if len(follows) != 0 {
+		t.Errorf("bob follows %+v after the failed addfeed", follows)
+	}
+}
+
+func TestFollowFeedAddedByAnotherUser(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	bob := createTestUser(t, s, "bob")
+	addTestFeed(t, s, alice, "News", "http://example.com/feed.xml")
+
+	output, err := run(t, s, bob, handlerFollow, "http://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	if !strings.Contains(output, "User <bob> is now following 'News'.") {
+		t.Errorf("unexpected output:\n%v", output)
+	}
+
+	output, err = run(t, s, bob, handlerFollowing)
+	if err != nil {
+		t.Fatalf("following: %v", err)
+	}
+	if !strings.Contains(output, "- News") {
+		t.Errorf("following doesn't list the feed:\n%v", output)
+	}
+}
+
+func TestFollowTwiceFails(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	addTestFeed(t, s, alice, "News", "http://example.com/feed.xml")
+
+	_, err := run(t, s, alice, handlerFollow, "http://example.com/feed.xml")
+	if err == nil {
+		t.Fatal("following a feed twice succeeded")
+	}
+}
+
+func TestFollowUnknownFeedFails(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+
+	_, err := run(t, s, alice, handlerFollow, "http://example.com/missing.xml")
+	if err == nil {
+		t.Fatal("following an unknown feed succeeded")
+	}
+}
+
+func TestScrapeStoresEachPostOnce(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server := newFeedServer(t,
+		testItem{title: "First", link: "http://example.com/1", pubDate: published},
+		testItem{title: "Second", link: "http://example.com/2", pubDate: published.Add(time.Hour)},
+	)
+	addTestFeed(t, s, alice, "Test", server.URL+"/feed.xml")
+
+	for i := 0; i < 2; i++ {
+		// Make the feed due again.
+		err := s.db.ResetFeedFetchState(context.Background())
+		if err != nil {
+			t.Fatalf("resetting the fetch state: %v", err)
+		}
+		err = scrape(t, s)
+		if err != nil {
+			t.Fatalf("scrape %v: %v", i+1, err)
+		}
+	}
+
+	posts, err := s.db.GetAllPostsForUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatalf("getting the posts: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("stored %v posts, want 2", len(posts))
+	}
+	if posts[0].Title != "First" || !posts[0].PublishedAt.Time.Equal(published) {
+		t.Errorf("first post = %+v", posts[0])
+	}
+	feedData, _ := s.db.GetFeedFromURL(context.Background(), server.URL+"/feed.xml")
+	if !feedData.LastFetchedAt.Valid {
+		t.Error("the feed wasn't marked as fetched")
+	}
+}
+
+func TestScrapeFailingFeed(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	server := newFeedServer(t)
+	addTestFeed(t, s, alice, "Broken", server.URL+"/missing.xml")
+
+	err := scrape(t, s)
+	if err != nil {
+		t.Fatalf("a feed answering 404 stopped the scrape: %v", err)
+	}
+	fetch := lastFetch(t, s, server.URL+"/missing.xml")
+	if fetch.StatusCode.Int32 != http.StatusNotFound || !strings.Contains(fetch.Error.String, "404") {
+		t.Errorf("recorded the fetch as %+v", fetch)
+	}
+	// The feed goes to the back of the queue so it doesn't block the others.
+	feedData, _ := s.db.GetFeedFromURL(context.Background(), server.URL+"/missing.xml")
+	if !feedData.LastFetchedAt.Valid {
+		t.Error("the failing feed wasn't marked as fetched")
+	}
+}
+
+func TestScrapeWaitsForTheFeedTTL(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Slow feed</title><ttl>90</ttl></channel></rss>`)
+	}))
+	t.Cleanup(server.Close)
+	addTestFeed(t, s, alice, "Slow", server.URL+"/feed.xml")
+
+	for i := 0; i < 2; i++ {
+		err := scrape(t, s)
+		if err != nil {
+			t.Fatalf("scrape %v: %v", i+1, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("fetched the feed %v times, want 1", requests)
+	}
+	feedData, _ := s.db.GetFeedFromURL(context.Background(), server.URL+"/feed.xml")
+	if !feedData.NextFetchAt.Valid || feedData.NextFetchAt.Time.Sub(feedData.LastFetchedAt.Time) != 90*time.Minute {
+		t.Errorf("next fetch at %v, want 90 minutes after %v", feedData.NextFetchAt, feedData.LastFetchedAt)
+	}
+}
+
+func TestScrapeLearnsThePostingCadence(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	now := time.Now()
+	server := newFeedServer(t,
+		testItem{title: "First", link: "http://example.com/1", pubDate: now.Add(-4 * time.Hour)},
+		testItem{title: "Second", link: "http://example.com/2", pubDate: now.Add(-3 * time.Hour)},
+		testItem{title: "Third", link: "http://example.com/3", pubDate: now.Add(-2 * time.Hour)},
+		testItem{title: "Fourth", link: "http://example.com/4", pubDate: now.Add(-1 * time.Hour)},
+	)
+	addTestFeed(t, s, alice, "Hourly", server.URL+"/feed.xml")
+	err := scrape(t, s)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+
+	output, err := captureOutput(t, func() error { return handlerSchedule(s, command{}) })
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	// Posting hourly, the feed is fetched every half hour.
+	if !strings.Contains(output, "Fetched every: 30m0s (learned from its posts)") {
+		t.Errorf("unexpected output:\n%v", output)
+	}
+}
+
+func TestScrapeWorkersShareTheHostLimits(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	var mu sync.Mutex
+	running, maxRunning, requests := 0, 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		requests++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Busy host</title></channel></rss>`)
+	}))
+	t.Cleanup(server.Close)
+	for i := 0; i < 4; i++ {
+		addTestFeed(t, s, alice, fmt.Sprintf("Feed %v", i), fmt.Sprintf("%v/feed-%v.xml", server.URL, i))
+	}
+	s.fetcher = newTestFetcher(t, config.FetchConfig{
+		Hosts: map[string]config.HostLimitConfig{
+			"127.0.0.1": {MaxConcurrent: 1, MinDelay: "0s"},
+		},
+	})
+
+	_, err := captureOutput(t, func() error { return scrapeWorkers(s, 4) })
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	if requests != 4 || maxRunning != 1 {
+		t.Errorf("fetched %v feeds, up to %v at once; want 4, one at a time", requests, maxRunning)
+	}
+}
+
+func TestScrapeWorkersCarryOnPastAFailingFeed(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server := newFeedServer(t, testItem{title: "Fine", link: "http://example.com/1", pubDate: published})
+	addTestFeed(t, s, alice, "Good", server.URL+"/feed.xml")
+	addTestFeed(t, s, alice, "Broken", server.URL+"/missing.xml")
+	addTestFeed(t, s, alice, "Also good", server.URL+"/feed.xml?copy")
+
+	// Each round is one tick of agg.
+	for i := 0; i < 2; i++ {
+		err := s.db.ResetFeedFetchState(context.Background())
+		if err != nil {
+			t.Fatalf("resetting the fetch state: %v", err)
+		}
+		_, err = captureOutput(t, func() error { return scrapeWorkers(s, 3) })
+		if err != nil {
+			t.Fatalf("round %v: a failing feed stopped the workers: %v", i+1, err)
+		}
+	}
+
+	for _, feedURL := range []string{server.URL + "/feed.xml", server.URL + "/feed.xml?copy"} {
+		fetch := lastFetch(t, s, feedURL)
+		if fetch.Error.Valid || fetch.Items != 1 {
+			t.Errorf("recorded the fetch of %v as %+v", feedURL, fetch)
+		}
+	}
+	if fetch := lastFetch(t, s, server.URL+"/missing.xml"); !fetch.Error.Valid {
+		t.Errorf("recorded the failing fetch as %+v", fetch)
+	}
+}
+
+func TestScrapeGivesUpOnHangingFeed(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	userAgents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.UserAgent()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	addTestFeed(t, s, alice, "Hanging", server.URL+"/feed.xml")
+	s.fetcher = newTestFetcher(t, config.FetchConfig{
+		Timeout:    "100ms",
+		ContactURL: "https://example.com/gator",
+	})
+
+	err := scrape(t, s)
+	if err != nil {
+		t.Fatalf("a feed that never answers stopped the scrape: %v", err)
+	}
+	fetch := lastFetch(t, s, server.URL+"/feed.xml")
+	if fetch.StatusCode.Valid || !strings.Contains(fetch.Error.String, "Timeout") {
+		t.Errorf("recorded the fetch as %+v", fetch)
+	}
+	if userAgent := <-userAgents; userAgent != "gator/dev (+https://example.com/gator)" {
+		t.Errorf("sent User-Agent %q", userAgent)
+	}
+}
+
+func TestScrapeRefusesInternalAddresses(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	server := newFeedServer(t, testItem{title: "Secret", link: "http://example.com/secret", pubDate: time.Now()})
+	addTestFeed(t, s, alice, "Internal", server.URL+"/feed.xml")
+	// Unlike the test fetchers, these don't allow 127.0.0.1 by default.
+	fetcher := func(conf config.FetchConfig) *rss.Fetcher {
+		conf.PerHost.MinDelay = "0s"
+		fetcher, err := rss.NewFetcher(conf)
+		if err != nil {
+			t.Fatalf("creating the fetcher: %v", err)
+		}
+		return fetcher
+	}
+	s.fetcher = fetcher(config.FetchConfig{})
+
+	err := scrape(t, s)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	fetch := lastFetch(t, s, server.URL+"/feed.xml")
+	if !strings.Contains(fetch.Error.String, "internal address 127.0.0.1") {
+		t.Fatalf("recorded the loopback fetch as %+v", fetch)
+	}
+
+	s.fetcher = fetcher(config.FetchConfig{AllowNetworks: []string{"127.0.0.0/8"}})
+	err = s.db.ResetFeedFetchState(context.Background())
+	if err != nil {
+		t.Fatalf("resetting the fetch state: %v", err)
+	}
+	err = scrape(t, s)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	fetch = lastFetch(t, s, server.URL+"/feed.xml")
+	if fetch.Error.Valid {
+		t.Errorf("fetching from an allowed network failed: %v", fetch.Error.String)
+	}
+}
+
+func TestScrapeRejectsHostileFeeds(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		outcome string
+	}{
+		{
+			name:    "oversized",
+			body:    `<rss><channel><title>` + strings.Repeat("x", 4096) + `</title></channel></rss>`,
+			want:    "over the limit of 1024 bytes",
+			outcome: metrics.OutcomeRejected,
+		},
+		{
+			name:    "entity expansion",
+			body:    `<?xml version="1.0"?><!DOCTYPE rss [<!ENTITY a "aaaaaaaaaa"><!ENTITY b "&a;&a;&a;&a;">]><rss><channel><title>&b;</title></channel></rss>`,
+			want:    "declares entities",
+			outcome: metrics.OutcomeRejected,
+		},
+		{
+			name:    "deep nesting",
+			body:    `<rss><channel>` + strings.Repeat("<x>", 100) + strings.Repeat("</x>", 100) + `</channel></rss>`,
+			want:    "nested deeper than",
+			outcome: metrics.OutcomeRejected,
+		},
+		{
+			name:    "not xml",
+			body:    strings.Repeat("not a feed ", 50),
+			want:    `It starts with: "not a feed`,
+			outcome: metrics.OutcomeParseError,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, alice := newTestState(t, "alice")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Flushing drops the Content-Length, so the limit applies
+				// while reading.
+				w.(http.Flusher).Flush()
+				io.WriteString(w, test.body)
+			}))
+			t.Cleanup(server.Close)
+			addTestFeed(t, s, alice, "Hostile", server.URL+"/feed.xml")
+			s.fetcher = newTestFetcher(t, config.FetchConfig{
+				MaxBodyBytes: 1024,
+			})
+
+			before := scrapeMetrics(t)
+			err := scrape(t, s)
+			if err != nil {
+				t.Fatalf("scrape: %v", err)
+			}
+			series := fmt.Sprintf(`gator_feed_fetches_total{outcome="%v"}`, test.outcome)
+			if got := scrapeMetrics(t)[series] - before[series]; got != 1 {
+				t.Errorf("%v went up by %v, want 1", series, got)
+			}
+			fetch := lastFetch(t, s, server.URL+"/feed.xml")
+			if !strings.Contains(fetch.Error.String, test.want) {
+				t.Fatalf("recorded the error %q; want one containing %q", fetch.Error.String, test.want)
+			}
+			if len(fetch.Error.String) > 500 {
+				t.Errorf("the error quotes too much of the feed: %v bytes", len(fetch.Error.String))
+			}
+		})
+	}
+}
+
+func TestBrowseShowsNewestFollowedPostsFirst(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	bob := createTestUser(t, s, "bob")
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	followed := newFeedServer(t,
+		testItem{title: "Older", link: "http://a.example.com/1", pubDate: published},
+		testItem{title: "Newer", link: "http://a.example.com/2", pubDate: published.Add(time.Hour)},
+		testItem{title: "Newest", link: "http://a.example.com/3", pubDate: published.Add(2 * time.Hour)},
+	)
+	other := newFeedServer(t,
+		testItem{title: "Not followed", link: "http://b.example.com/1", pubDate: published.Add(3 * time.Hour)},
+	)
+	addTestFeed(t, s, alice, "Followed", followed.URL+"/feed.xml")
+	addTestFeed(t, s, bob, "Other", other.URL+"/feed.xml")
+	for i := 0; i < 2; i++ {
+		err := scrape(t, s)
+		if err != nil {
+			t.Fatalf("scrape: %v", err)
+		}
+	}
+
+	output, err := run(t, s, alice, handlerBrowse, "2")
+	if err != nil {
+		t.Fatalf("browse: %v", err)
+	}
+	newest := strings.Index(output, "| Newest |")
+	newer := strings.Index(output, "| Newer |")
+	if newest < 0 || newer < 0 || newer < newest {
+		t.Errorf("browse didn't list Newest then Newer:\n%v", output)
+	}
+	if strings.Contains(output, "| Older |") {
+		t.Errorf("browse ignored the limit:\n%v", output)
+	}
+	if strings.Contains(output, "Not followed") {
+		t.Errorf("browse listed a post from a feed alice doesn't follow:\n%v", output)
+	}
+	if !strings.Contains(output, "From: Followed") {
+		t.Errorf("browse didn't name the feed:\n%v", output)
+	}
+}
+
+func TestBrowseByTag(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tech := newFeedServer(t, testItem{title: "Compilers", link: "http://tech.example.com/1", pubDate: published})
+	food := newFeedServer(t, testItem{title: "Bread", link: "http://food.example.com/1", pubDate: published})
+	for name, server := range map[string]*httptest.Server{"Tech": tech, "Food": food} {
+		addTestFeed(t, s, alice, name, server.URL+"/feed.xml")
+		err := scrape(t, s)
+		if err != nil {
+			t.Fatalf("scrape: %v", err)
+		}
+	}
+	_, err := run(t, s, alice, handlerTag, tech.URL+"/feed.xml", "tech")
+	if err != nil {
+		t.Fatalf("tag: %v", err)
+	}
+
+	output, err := run(t, s, alice, handlerBrowse, "10", "--tag", "tech")
+	if err != nil {
+		t.Fatalf("browse: %v", err)
+	}
+	if !strings.Contains(output, "| Compilers |") || strings.Contains(output, "| Bread |") {
+		t.Errorf("browse --tag tech listed the wrong posts:\n%v", output)
+	}
+}
+
+func TestBrowseRejectsNonNumericLimit(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+
+	_, err := run(t, s, alice, handlerBrowse, "many")
+	if err == nil {
+		t.Fatal("browse with a non numeric limit succeeded")
+	}
+}
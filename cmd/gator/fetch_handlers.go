@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"database/sql"
+	"errors"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/metrics"
+	"github.com/Mr-Rafael/gator/internal/retention"
+	"github.com/Mr-Rafael/gator/internal/rss"
+)
+
+func handlerFeedLog(s *state, cmd command) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected a feed url (feed-log <url> [limit])")
+	}
+	limit := 20
+	if len(cmd.Arguments) >= 2 {
+		var err error
+		limit, err = strconv.Atoi(cmd.Arguments[1])
+		if err != nil {
+			return fmt.Errorf("Expected a numeric parameter for command. %v", err)
+		}
+		if limit < 1 {
+			return fmt.Errorf("Error: the limit must be at least 1")
+		}
+	}
+
+	feedData, err := s.db.GetFeedFromURL(context.Background(), cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error getting the feed data: %v", err)
+	}
+	fetchesParams := database.GetFeedFetchesParams {
+		FeedID: feedData.ID,
+		Limit: int32(limit),
+	}
+	fetches, err := s.db.GetFeedFetches(context.Background(), fetchesParams)
+	if err != nil {
+		return fmt.Errorf("Error getting the fetch log: %v", err)
+	}
+
+	if len(fetches) == 0 {
+		fmt.Printf("\nFeed '%v' hasn't been fetched yet.\n", feedData.Name)
+		return nil
+	}
+	fmt.Printf("\nLatest fetches of feed '%v':\n", feedData.Name)
+	for _, fetch := range fetches {
+		status := "no response"
+		if fetch.StatusCode.Valid {
+			status = fmt.Sprintf("HTTP %v", fetch.StatusCode.Int32)
+		}
+		fmt.Printf("\n| %v | %v | %v |\n", fetch.StartedAt.Local().Format(time.DateTime), status, fetch.FinishedAt.Sub(fetch.StartedAt).Round(time.Millisecond))
+		fmt.Printf("Received %v bytes, %v items, %v new posts\n", fetch.Bytes, fetch.Items, fetch.NewPosts)
+		if fetch.Error.Valid {
+			fmt.Printf("Failed: %v\n", fetch.Error.String)
+		}
+	}
+	return nil
+}
+
+// recordFetch adds a fetch attempt to the feed's fetch log and trims the
+// log to its configured size. A failure to record it is reported, but
+// doesn't stop the scrape.
+func recordFetch(s *state, logger *slog.Logger, fetch database.CreateFeedFetchParams, scrapeErr error) {
+	fetch.FinishedAt = time.Now()
+	if scrapeErr != nil {
+		fetch.Error = sql.NullString{String: scrapeErr.Error(), Valid: true}
+	}
+	metrics.FeedFetches.WithLabelValues(fetchOutcome(fetch, scrapeErr)).Inc()
+	metrics.FetchDuration.Observe(fetch.FinishedAt.Sub(fetch.StartedAt).Seconds())
+	metrics.FetchedBytes.Add(float64(fetch.Bytes))
+	metrics.PostsInserted.Add(float64(fetch.NewPosts))
+
+	err := s.db.CreateFeedFetch(context.Background(), fetch)
+	if err != nil {
+		logger.Error("Error recording the fetch", "error", err)
+		return
+	}
+
+	trimParams := database.TrimFeedFetchesParams {
+		FeedID: fetch.FeedID,
+		Keep: int32(retention.MaxFetches(s.Configuration.Retention)),
+	}
+	err = s.db.TrimFeedFetches(context.Background(), trimParams)
+	if err != nil {
+		logger.Error("Error trimming the fetch log", "error", err)
+	}
+}
+
+// fetchOutcome tells how far a fetch got before failing. Feeds refused
+// for their size or by the XML guards are told from malformed ones. The
+// items are only counted once the feed parses, and a feed without items
+// has nothing to store.
+func fetchOutcome(fetch database.CreateFeedFetchParams, scrapeErr error) string {
+	switch {
+	case scrapeErr == nil:
+		return metrics.OutcomeSuccess
+	case !fetch.StatusCode.Valid:
+		return metrics.OutcomeNetworkError
+	case fetch.StatusCode.Int32 < 200 || fetch.StatusCode.Int32 >= 300:
+		return metrics.OutcomeHTTPError
+	case errors.Is(scrapeErr, rss.ErrFeedTooLarge) || errors.Is(scrapeErr, rss.ErrUnsafeFeed):
+		return metrics.OutcomeRejected
+	case fetch.Items == 0:
+		return metrics.OutcomeParseError
+	default:
+		return metrics.OutcomeStoreError
+	}
+}
+
+// serveMetrics starts the metrics listener in the background. The address
+// is bound right away, so agg fails early when it's taken.
+func serveMetrics(s *state, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("Error starting the metrics listener: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	go func() {
+		err := http.Serve(listener, mux)
+		if err != nil {
+			s.logger.Error("Error serving the metrics", "error", err)
+		}
+	}()
+	s.logger.Info("Serving metrics", "url", fmt.Sprintf("http://%v/metrics", listener.Addr()))
+	return nil
+}
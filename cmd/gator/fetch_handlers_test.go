@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/metrics"
+	"github.com/Mr-Rafael/gator/internal/migrations"
+	"github.com/Mr-Rafael/gator/internal/storage"
+)
+
+func TestFeedLogKeepsTheLatestFetches(t *testing.T) {
+	const maxFetches = 3
+	s, alice := newTestState(t, "alice")
+	s.Configuration.Retention.MaxFetchesPerFeed = maxFetches
+	server := newFeedServer(t, testItem{title: "First", link: "http://example.com/1", pubDate: time.Now()})
+	feedURL := server.URL + "/feed.xml"
+	addTestFeed(t, s, alice, "News", feedURL)
+
+	for i := 0; i < maxFetches+1; i++ {
+		err := s.db.ResetFeedFetchState(context.Background())
+		if err != nil {
+			t.Fatalf("resetting the fetch state: %v", err)
+		}
+		err = scrape(t, s)
+		if err != nil {
+			t.Fatalf("scrape %v: %v", i+1, err)
+		}
+	}
+
+	feedData, err := s.db.GetFeedFromURL(context.Background(), feedURL)
+	if err != nil {
+		t.Fatalf("getting the feed: %v", err)
+	}
+	fetches, err := s.db.GetFeedFetches(context.Background(), database.GetFeedFetchesParams{FeedID: feedData.ID, Limit: 100})
+	if err != nil {
+		t.Fatalf("getting the fetches: %v", err)
+	}
+	if len(fetches) != maxFetches {
+		t.Fatalf("kept %v fetches, want %v", len(fetches), maxFetches)
+	}
+	// The first fetch, the only one with a new post, was trimmed.
+	for _, fetch := range fetches {
+		if fetch.NewPosts != 0 {
+			t.Errorf("kept the first fetch: %+v", fetch)
+		}
+	}
+
+	feedLog := func(arguments ...string) (string, error) {
+		return captureOutput(t, func() error {
+			return handlerFeedLog(s, command{Arguments: arguments})
+		})
+	}
+	output, err := feedLog(feedURL)
+	if err != nil {
+		t.Fatalf("feed-log: %v", err)
+	}
+	if count := strings.Count(output, "Received "); count != maxFetches {
+		t.Errorf("feed-log listed %v fetches, want %v:\n%v", count, maxFetches, output)
+	}
+	output, err = feedLog(feedURL, "2")
+	if err != nil {
+		t.Fatalf("feed-log with a limit: %v", err)
+	}
+	if count := strings.Count(output, "Received "); count != 2 {
+		t.Errorf("feed-log listed %v fetches, want 2:\n%v", count, output)
+	}
+	for _, limit := range []string{"0", "-1"} {
+		_, err = feedLog(feedURL, limit)
+		if err == nil {
+			t.Errorf("feed-log accepted the limit %v", limit)
+		}
+	}
+}
+
+// scrapeMetrics reads the metrics served on /metrics, by series.
+func scrapeMetrics(t *testing.T) map[string]float64 {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	values := map[string]float64{}
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		series, value, _ := strings.Cut(line, " ")
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			t.Fatalf("parsing the metric line %q: %v", line, err)
+		}
+		values[series] = number
+	}
+	return values
+}
+
+func TestMetricsCountFetchOutcomesAndQueryErrors(t *testing.T) {
+	// Query errors are counted under the SQL stores, so this runs on SQLite
+	// rather than in memory.
+	store, err := storage.Open("sqlite:" + filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatalf("opening the database: %v", err)
+	}
+	t.Cleanup(func() { store.Conn.Close() })
+	provider, err := migrations.NewProvider(store)
+	if err != nil {
+		t.Fatalf("loading the migrations: %v", err)
+	}
+	_, err = provider.Up(context.Background())
+	if err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	s, _ := newTestState(t, "alice")
+	s.db = store.Queries
+	s.store = store
+	alice := createTestUser(t, s, "alice")
+
+	server := newFeedServer(t, testItem{title: "First", link: "http://example.com/1", pubDate: time.Now()})
+	addTestFeed(t, s, alice, "Good", server.URL+"/feed.xml")
+	addTestFeed(t, s, alice, "Missing", server.URL+"/missing.xml")
+
+	before := scrapeMetrics(t)
+	for i := 0; i < 2; i++ {
+		err = scrape(t, s)
+		if err != nil {
+			t.Fatalf("scrape %v: %v", i+1, err)
+		}
+	}
+	// Following a feed twice breaks a unique constraint.
+	_, err = run(t, s, alice, handlerFollow, server.URL+"/feed.xml")
+	if err == nil {
+		t.Fatal("followed the same feed twice")
+	}
+	after := scrapeMetrics(t)
+
+	for series, want := range map[string]float64{
+		`gator_feed_fetches_total{outcome="success"}`:     1,
+		`gator_feed_fetches_total{outcome="http_error"}`:  1,
+		`gator_feed_fetches_total{outcome="parse_error"}`: 0,
+		`gator_posts_inserted_total`:                      1,
+		`gator_db_query_errors_total`:                     1,
+	} {
+		if got := after[series] - before[series]; got != want {
+			t.Errorf("%v went up by %v, want %v", series, got, want)
+		}
+	}
+}
@@ -1,23 +1,48 @@
 package main
 
-import _ "github.com/lib/pq"
-
 import (
 	"fmt"
 	"os"
+	"bufio"
+	"flag"
+	"log/slog"
+	"net/http"
+	"strings"
 	"context"
 	"encoding/json"
-	"database/sql"
 	"github.com/Mr-Rafael/gator/internal/config"
 	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/logging"
+	"github.com/Mr-Rafael/gator/internal/migrations"
+	"github.com/Mr-Rafael/gator/internal/rss"
+	"github.com/Mr-Rafael/gator/internal/storage"
 )
 
 type state struct {
-	db *database.Queries
+	logger *slog.Logger
+	db database.Querier
+	store *storage.DB
+	fetcher *rss.Fetcher
+	hookClient *http.Client
 	Configuration *config.Config
 }
 
 func main() {
+	flags := flag.NewFlagSet("gator", flag.ExitOnError)
+	logLevel := flags.String("log-level", logging.DefaultLevel, "lowest level logged: debug, info, warn or error")
+	logFormat := flags.String("log-format", logging.DefaultFormat, "log format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: gator [--log-level level] [--log-format text|json] <command> [arguments]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+	logger, err := logging.New(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\n%v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
 	validCommands := make(map[string]func(*state, command) error)
 	commands := commands{
 		ValidCommands: validCommands,
@@ -25,39 +50,74 @@ func main() {
 	commands.register("register", handlerRegister)
 	commands.register("login", handlerLogin)
 	commands.register("users", handlerUsers)
-	commands.register("addfeed", middlewareLoggedIn(handlerAddFeed))
+	commands.register("addfeed", middlewareLoggedIn(logger, handlerAddFeed))
 	commands.register("feeds", handlerFeeds)
 	commands.register("agg", handlerAgg)
-	commands.register("follow", middlewareLoggedIn(handlerFollow))
-	commands.register("following", middlewareLoggedIn(handlerFollowing))
-	commands.register("unfollow", middlewareLoggedIn(handlerUnfollow))
-	commands.register("browse", middlewareLoggedIn(handlerBrowse))
+	commands.register("follow", middlewareLoggedIn(logger, handlerFollow))
+	commands.register("following", middlewareLoggedIn(logger, handlerFollowing))
+	commands.register("unfollow", middlewareLoggedIn(logger, handlerUnfollow))
+	commands.register("browse", middlewareLoggedIn(logger, handlerBrowse))
 	commands.register("reset", handlerReset)
+	commands.register("setpassword", middlewareLoggedIn(logger, handlerSetPassword))
+	commands.register("serve", handlerServe)
+	commands.register("webhooks", middlewareLoggedIn(logger, handlerWebhooks))
+	commands.register("setemail", middlewareLoggedIn(logger, handlerSetEmail))
+	commands.register("digest", handlerDigest)
+	commands.register("rules", middlewareLoggedIn(logger, handlerRules))
+	commands.register("alert", middlewareLoggedIn(logger, handlerAlert))
+	commands.register("alerts", middlewareLoggedIn(logger, handlerAlerts))
+	commands.register("tag", middlewareLoggedIn(logger, handlerTag))
+	commands.register("untag", middlewareLoggedIn(logger, handlerUntag))
+	commands.register("export", middlewareLoggedIn(logger, handlerExport))
+	commands.register("import", middlewareLoggedIn(logger, handlerImport))
+	commands.register("rename-feed", middlewareLoggedIn(logger, handlerRenameFeed))
+	commands.register("editfeed", middlewareLoggedIn(logger, handlerEditFeed))
+	commands.register("deletefeed", middlewareLoggedIn(logger, handlerDeleteFeed))
+	commands.register("transfer-feed", middlewareLoggedIn(logger, handlerTransferFeed))
+	commands.register("renameuser", middlewareLoggedIn(logger, handlerRenameUser))
+	commands.register("deleteuser", middlewareLoggedIn(logger, handlerDeleteUser))
+	commands.register("promote", middlewareLoggedIn(logger, handlerPromote))
+	commands.register("demote", middlewareLoggedIn(logger, handlerDemote))
+	commands.register("migrate", handlerMigrate)
+	commands.register("prune", handlerPrune)
+	commands.register("feed-log", handlerFeedLog)
+	commands.register("schedule", handlerSchedule)
 
 	currentConf, err := config.Read()
 	if err != nil {
-		fmt.Printf("\nError reading configuration: %v", err)
+		logger.Error("Error reading configuration", "error", err)
 	}
-	db, err := sql.Open("postgres", currentConf.DBURL)
+	store, err := storage.Open(currentConf.DBURL)
 	if err != nil {
-		fmt.Printf("\nError connecting to the database: %v", err)
+		logger.Error("Error opening the database", "error", err)
+		os.Exit(1)
 	}
-	dbQueries := database.New(db)
 	currentState := &state{
+		logger: logger,
 		Configuration: &currentConf,
-		db: dbQueries,
+		db: store.Queries,
+		store: store,
 	}
 
-	args := os.Args
-	if len(args) < 2 {
-		fmt.Println("Error: Received less arguments than expected")
+	args := flags.Args()
+	if len(args) < 1 {
+		logger.Error("Received less arguments than expected")
+		flags.Usage()
 		os.Exit(1)
 	}
 	receivedCommand := getCommand(args)
 
+	if receivedCommand.Name != "migrate" {
+		err = migrations.Check(context.Background(), store)
+		if err != nil {
+			logger.Error("Error checking the database schema", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	err =commands.run(currentState, receivedCommand)
 	if err != nil {
-		fmt.Printf("\nError running command: |%v|\n", err)
+		logger.Error("Error running command", "error", err)
 		os.Exit(1)
 	}
 }
@@ -77,15 +137,16 @@ func getCurrentUserData(s * state) (database.User, error) {
 func updateConfig(s *state) {
 	updatedConfig, err := config.Read()
 	if err != nil {
-		fmt.Printf("\nError while updating config: %v", err)
+		s.logger.Error("Error while updating config", "error", err)
 	}
 	s.Configuration = &updatedConfig
-	printStruct("Successfully updated config:", s.Configuration)
+	// Only the user is shown, as the config also holds credentials.
+	fmt.Printf("\nSuccessfully updated config. Current user: %v\n", s.Configuration.CurrentUserName)
 }
 
 func getCommand(arguments []string) command {
-	commandName := arguments[1]
-	commandArguments := arguments[2:]
+	commandName := arguments[0]
+	commandArguments := arguments[1:]
 	return command{
 		Name: commandName,
 		Arguments: commandArguments,
@@ -101,18 +162,39 @@ func printStruct(description string, inter interface{}) {
 	fmt.Printf("\n%v\n%v\n", description, string(readable))
 }
 
-func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
+// confirm asks a yes/no question on the terminal. Anything but "y" or
+// "yes" counts as a no.
+func confirm(question string) bool {
+	fmt.Printf("\n%v [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func hasFlag(arguments []string, name string) bool {
+	for _, argument := range arguments {
+		if argument == name {
+			return true
+		}
+	}
+	return false
+}
+
+func middlewareLoggedIn(logger *slog.Logger, handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	currentConf, err := config.Read()
 	if err != nil {
-		fmt.Printf("\nError reading configuration: %v", err)
+		logger.Error("Error reading configuration", "error", err)
 		return nil
 	}
-	db, err := sql.Open("postgres", currentConf.DBURL)
+	store, err := storage.Open(currentConf.DBURL)
 	if err != nil {
-		fmt.Printf("\nError connecting to the database: %v", err)
+		logger.Error("Error opening the database", "error", err)
 		return nil
 	}
-	dbQueries := database.New(db)
+	dbQueries := store.Queries
 	
 	userName, err := config.GetCurrentUser()
 	if err != nil {
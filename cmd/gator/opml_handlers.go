@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/opml"
+	"github.com/Mr-Rafael/gator/internal/rss"
+)
+
+func handlerExport(s *state, cmd command, userData database.User) error {
+	feeds, err := s.db.GetFollowedFeeds(context.Background(), userData.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting the followed feeds: %v", err)
+	}
+	tags, err := s.db.GetFeedFollowTagsForUser(context.Background(), userData.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting the feed tags: %v", err)
+	}
+
+	subscriptions := []opml.Subscription{}
+	for _, feedData := range feeds {
+		subscription := opml.Subscription {
+			Title: feedData.Title,
+			URL: feedData.Url,
+			Tags: []string{},
+		}
+		for _, feedTag := range tags {
+			if feedTag.FeedID == feedData.ID {
+				subscription.Tags = append(subscription.Tags, feedTag.Tag)
+			}
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	var out io.Writer = os.Stdout
+	if len(cmd.Arguments) >= 1 {
+		file, err := os.Create(cmd.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("Error creating the export file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	err = opml.Write(out, fmt.Sprintf("Feeds followed by %v", userData.Name), subscriptions)
+	if err != nil {
+		return err
+	}
+	if len(cmd.Arguments) >= 1 {
+		fmt.Printf("\nExported %v feeds to %v.\n", len(subscriptions), cmd.Arguments[0])
+	}
+	return nil
+}
+
+func handlerImport(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected 1 argument (file), and found %v", len(cmd.Arguments))
+	}
+	file, err := os.Open(cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error opening the import file: %v", err)
+	}
+	defer file.Close()
+
+	subscriptions, err := opml.Read(file)
+	if err != nil {
+		return err
+	}
+
+	imported, skipped := 0, 0
+	for _, subscription := range subscriptions {
+		_, err = rss.ParseFeedURL(subscription.URL)
+		if err != nil {
+			fmt.Printf("- Skipping '%v': %v\n", subscription.Title, err)
+			skipped++
+			continue
+		}
+		follow, err := importSubscription(s, userData, subscription)
+		if err != nil {
+			return err
+		}
+		for _, tag := range subscription.Tags {
+			tagParams := database.AddFeedFollowTagParams {
+				FeedFollowID: follow.ID,
+				Tag: tag,
+				CreatedAt: time.Now(),
+			}
+			err = s.db.AddFeedFollowTag(context.Background(), tagParams)
+			if err != nil {
+				return fmt.Errorf("Error tagging the feed '%v': %v", subscription.URL, err)
+			}
+		}
+		fmt.Printf("- Following '%v'\n", subscription.Title)
+		imported++
+	}
+	fmt.Printf("\nImported %v feeds for user <%v>.\n", imported, userData.Name)
+	if skipped > 0 {
+		fmt.Printf("Skipped %v entries with an invalid feed url.\n", skipped)
+	}
+	return nil
+}
+
+// importSubscription makes the user follow an imported feed, adding the
+// feed first if it isn't known yet, and returns the follow.
+func importSubscription(s *state, userData database.User, subscription opml.Subscription) (database.FeedFollow, error) {
+	feedData, err := s.db.GetFeedFromURL(context.Background(), subscription.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		feedCreationParams := database.CreateFeedParams {
+			ID: uuid.New(),
+			Name: subscription.Title,
+			Url: subscription.URL,
+			UserID: uuid.NullUUID{UUID: userData.ID, Valid: true},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		feedData, err = s.db.CreateFeed(context.Background(), feedCreationParams)
+	}
+	if err != nil {
+		return database.FeedFollow{}, fmt.Errorf("Error getting the feed '%v': %v", subscription.URL, err)
+	}
+
+	followParams := database.GetFeedFollowParams {
+		UserID: userData.ID,
+		FeedID: feedData.ID,
+	}
+	_, err = s.db.GetFeedFollow(context.Background(), followParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		followCreationParams := database.CreateFeedFollowParams {
+			ID: uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID: userData.ID,
+			FeedID: feedData.ID,
+		}
+		_, err = s.db.CreateFeedFollow(context.Background(), followCreationParams)
+	}
+	if err != nil {
+		return database.FeedFollow{}, fmt.Errorf("Error creating follow in the database: %v", err)
+	}
+
+	// Keep the title the file uses when it differs from the feed's name.
+	if subscription.Title != feedData.Name {
+		renameParams := database.SetFeedFollowTitleParams {
+			UserID: userData.ID,
+			FeedID: feedData.ID,
+			Title: optionalString(subscription.Title),
+			UpdatedAt: time.Now(),
+		}
+		_, err = s.db.SetFeedFollowTitle(context.Background(), renameParams)
+		if err != nil {
+			return database.FeedFollow{}, fmt.Errorf("Error renaming the feed '%v': %v", subscription.URL, err)
+		}
+	}
+	return s.db.GetFeedFollow(context.Background(), followParams)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExportedTagsImportUnchanged(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	bob := createTestUser(t, s, "bob")
+	addTestFeed(t, s, alice, "News", "http://example.com/feed.xml")
+
+	for _, tag := range []string{"tech/go", "local news"} {
+		_, err := run(t, s, alice, handlerTag, "http://example.com/feed.xml", tag)
+		if err != nil {
+			t.Fatalf("tagging the feed as %q: %v", tag, err)
+		}
+	}
+	for _, tag := range []string{"news,local", "/news", "news/"} {
+		_, err := run(t, s, alice, handlerTag, "http://example.com/feed.xml", tag)
+		if err == nil {
+			t.Errorf("tagged the feed as %q, which OPML can't round-trip", tag)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "feeds.opml")
+	_, err := run(t, s, alice, handlerExport, path)
+	if err != nil {
+		t.Fatalf("exporting: %v", err)
+	}
+	_, err = run(t, s, bob, handlerImport, path)
+	if err != nil {
+		t.Fatalf("importing: %v", err)
+	}
+
+	tags := func(userName string) []string {
+		t.Helper()
+		userData, err := s.db.GetUser(context.Background(), userName)
+		if err != nil {
+			t.Fatalf("getting the user: %v", err)
+		}
+		feedTags, err := s.db.GetFeedFollowTagsForUser(context.Background(), userData.ID)
+		if err != nil {
+			t.Fatalf("getting the tags: %v", err)
+		}
+		result := []string{}
+		for _, feedTag := range feedTags {
+			result = append(result, feedTag.Tag)
+		}
+		slices.Sort(result)
+		return result
+	}
+	want := []string{"local news", "tech/go"}
+	if exported := tags("alice"); !slices.Equal(exported, want) {
+		t.Fatalf("exported the tags %q, want %q", exported, want)
+	}
+	if imported := tags("bob"); !slices.Equal(imported, want) {
+		t.Errorf("imported the tags %q, want %q", imported, want)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"errors"
+	"time"
+	"database/sql"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/retention"
+)
+
+// errDryRun rolls back the transaction of a dry run prune.
+var errDryRun = errors.New("dry run")
+
+// pruneResult counts the rows a prune freed, from the post data counts
+// taken before and after it.
+type pruneResult struct {
+	feeds int
+	before database.GetPostDataCountsRow
+	after database.GetPostDataCountsRow
+}
+
+func (r pruneResult) posts() int64 {
+	return r.before.Posts - r.after.Posts
+}
+
+func handlerPrune(s *state, cmd command) error {
+	err := requireAdmin(s)
+	if err != nil {
+		return err
+	}
+
+	dryRun := hasFlag(cmd.Arguments, "--dry-run")
+	result, err := prunePosts(s, dryRun)
+	if err != nil {
+		return err
+	}
+	printPruneResult(result, dryRun)
+	return nil
+}
+
+// prunePosts applies every feed's retention policy in a transaction. A
+// dry run rolls it back, so the counts are exact but nothing is deleted.
+func prunePosts(s *state, dryRun bool) (pruneResult, error) {
+	defaults, err := retention.FromConfig(s.Configuration.Retention)
+	if err != nil {
+		return pruneResult{}, err
+	}
+
+	result := pruneResult{}
+	err = s.store.InTx(context.Background(), func(q database.Querier) error {
+		before, err := q.GetPostDataCounts(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error counting the stored posts: %v", err)
+		}
+		result.before = before
+		feeds, err := q.GetAllFeeds(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error getting the feeds: %v", err)
+		}
+
+		now := time.Now()
+		for _, feedData := range feeds {
+			policy := retention.ForFeed(defaults, feedData)
+			if policy.KeepsEverything() {
+				continue
+			}
+			olderThan := sql.NullTime{}
+			cutoff, ok := policy.OlderThan(now)
+			if ok {
+				olderThan = sql.NullTime{Time: cutoff, Valid: true}
+			}
+
+			markParams := database.MarkPostsPrunedParams {
+				PrunedAt: now,
+				FeedID: feedData.ID,
+				OlderThan: olderThan,
+				KeepNewest: int32(policy.MaxPosts),
+			}
+			err = q.MarkPostsPruned(context.Background(), markParams)
+			if err != nil {
+				return fmt.Errorf("Error remembering the pruned posts of '%v': %v", feedData.Name, err)
+			}
+			pruneParams := database.PrunePostsParams {
+				FeedID: feedData.ID,
+				OlderThan: olderThan,
+				KeepNewest: int32(policy.MaxPosts),
+			}
+			pruned, err := q.PrunePosts(context.Background(), pruneParams)
+			if err != nil {
+				return fmt.Errorf("Error pruning the posts of '%v': %v", feedData.Name, err)
+			}
+			if pruned > 0 {
+				result.feeds++
+			}
+		}
+
+		after, err := q.GetPostDataCounts(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error counting the stored posts: %v", err)
+		}
+		result.after = after
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return pruneResult{}, err
+	}
+	return result, nil
+}
+
+func printPruneResult(result pruneResult, dryRun bool) {
+	freed := []struct {
+		description string
+		count int64
+	}{
+		{"posts", result.before.Posts - result.after.Posts},
+		{"read/starred states", result.before.PostStates - result.after.PostStates},
+		{"post tags", result.before.PostTags - result.after.PostTags},
+		{"webhook deliveries", result.before.WebhookDeliveries - result.after.WebhookDeliveries},
+		{"alert events", result.before.AlertEvents - result.after.AlertEvents},
+	}
+	var total int64
+	for _, rows := range freed {
+		total += rows.count
+	}
+
+	verb := "Pruned"
+	if dryRun {
+		verb = "Would prune"
+	}
+	fmt.Printf("\n%v %v posts from %v feeds, freeing %v rows:\n", verb, result.posts(), result.feeds, total)
+	for _, rows := range freed {
+		fmt.Printf("\t- %v %v\n", rows.count, rows.description)
+	}
+	if dryRun {
+		fmt.Println("\nDry run, nothing was deleted.")
+	}
+}
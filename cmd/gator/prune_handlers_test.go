@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+)
+
+func TestPrunedPostsAreForgottenOnceTheFeedDropsThem(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	first := testItem{title: "First", link: "http://example.com/1", pubDate: time.Now()}
+	second := testItem{title: "Second", link: "http://example.com/2", pubDate: time.Now()}
+	third := testItem{title: "Third", link: "http://example.com/3", pubDate: time.Now()}
+	var mu sync.Mutex
+	items := []testItem{first, second}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		writeFeed(w, items)
+	}))
+	t.Cleanup(server.Close)
+	addTestFeed(t, s, alice, "News", server.URL+"/feed.xml")
+	feedData, err := s.db.GetFeedFromURL(context.Background(), server.URL+"/feed.xml")
+	if err != nil {
+		t.Fatalf("getting the feed: %v", err)
+	}
+	scrapeAgain := func() {
+		t.Helper()
+		err := s.db.ResetFeedFetchState(context.Background())
+		if err != nil {
+			t.Fatalf("resetting the fetch state: %v", err)
+		}
+		err = scrape(t, s)
+		if err != nil {
+			t.Fatalf("scrape: %v", err)
+		}
+	}
+	prunedURLs := func() []string {
+		t.Helper()
+		urls, err := s.db.GetPrunedPostURLsForFeed(context.Background(), feedData.ID)
+		if err != nil {
+			t.Fatalf("getting the pruned posts: %v", err)
+		}
+		slices.Sort(urls)
+		return urls
+	}
+
+	scrapeAgain()
+	// Prune every post stored so far.
+	olderThan := sql.NullTime{Time: time.Now().Add(time.Second), Valid: true}
+	err = s.db.MarkPostsPruned(context.Background(), database.MarkPostsPrunedParams{
+		PrunedAt:  time.Now(),
+		FeedID:    feedData.ID,
+		OlderThan: olderThan,
+	})
+	if err != nil {
+		t.Fatalf("marking the posts pruned: %v", err)
+	}
+	_, err = s.db.PrunePosts(context.Background(), database.PrunePostsParams{FeedID: feedData.ID, OlderThan: olderThan})
+	if err != nil {
+		t.Fatalf("pruning: %v", err)
+	}
+
+	// While the feed lists them, they aren't stored again.
+	scrapeAgain()
+	if urls := prunedURLs(); !slices.Equal(urls, []string{first.link, second.link}) {
+		t.Errorf("remembers the pruned posts %v, want both", urls)
+	}
+
+	mu.Lock()
+	items = []testItem{second, third}
+	mu.Unlock()
+	scrapeAgain()
+	if urls := prunedURLs(); !slices.Equal(urls, []string{second.link}) {
+		t.Errorf("remembers the pruned posts %v, want only the one still listed", urls)
+	}
+	posts, err := s.db.GetAllPostsForUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatalf("getting the posts: %v", err)
+	}
+	if len(posts) != 1 || posts[0].Title != "Third" {
+		t.Errorf("stored %+v, want only the new post", posts)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"flag"
+	"io"
+	"strings"
+	"time"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/rules"
+)
+
+const rulesUsage = "rules add <action> [--feed url] [--title regex] [--description regex] [--author text] [--category text] [--tag name] | list | delete <id> | apply"
+
+func handlerRules(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected a subcommand (%v)", rulesUsage)
+	}
+	subcommand := cmd.Arguments[0]
+	arguments := cmd.Arguments[1:]
+
+	switch subcommand {
+	case "add":
+		return addRule(s, arguments, userData)
+	case "list":
+		return listRules(s, userData)
+	case "delete":
+		return deleteRule(s, arguments, userData)
+	case "apply":
+		return applyRulesRetroactively(s, userData)
+	}
+	return fmt.Errorf("Error: unknown subcommand '%v' (%v)", subcommand, rulesUsage)
+}
+
+func addRule(s *state, arguments []string, userData database.User) error {
+	if len(arguments) < 1 {
+		return fmt.Errorf("Error: expected an action (%v)", strings.Join(rules.Actions, ", "))
+	}
+	action := arguments[0]
+	if !rules.ValidAction(action) {
+		return fmt.Errorf("Error: unknown action '%v' (expected one of %v)", action, strings.Join(rules.Actions, ", "))
+	}
+
+	flags := flag.NewFlagSet("rules add", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	feedURL := flags.String("feed", "", "")
+	titlePattern := flags.String("title", "", "")
+	descriptionPattern := flags.String("description", "", "")
+	author := flags.String("author", "", "")
+	category := flags.String("category", "", "")
+	tag := flags.String("tag", "", "")
+	err := flags.Parse(arguments[1:])
+	if err != nil {
+		return fmt.Errorf("Error parsing the rule: %v (%v)", err, rulesUsage)
+	}
+	if *feedURL == "" && *titlePattern == "" && *descriptionPattern == "" && *author == "" && *category == "" {
+		return fmt.Errorf("Error: a rule needs at least one condition (--feed, --title, --description, --author or --category)")
+	}
+	if action == rules.ActionTag && *tag == "" {
+		return fmt.Errorf("Error: the tag action needs a --tag")
+	}
+
+	creationParams := database.CreateRuleParams {
+		ID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID: userData.ID,
+		TitlePattern: optionalString(*titlePattern),
+		DescriptionPattern: optionalString(*descriptionPattern),
+		Author: optionalString(*author),
+		Category: optionalString(*category),
+		Action: action,
+		Tag: optionalString(*tag),
+	}
+	if *feedURL != "" {
+		feedData, err := s.db.GetFeedFromURL(context.Background(), *feedURL)
+		if err != nil {
+			return fmt.Errorf("Error getting the feed data: %v", err)
+		}
+		creationParams.FeedID = uuid.NullUUID{UUID: feedData.ID, Valid: true}
+	}
+
+	ruleData := database.Rule {
+		FeedID: creationParams.FeedID,
+		TitlePattern: creationParams.TitlePattern,
+		DescriptionPattern: creationParams.DescriptionPattern,
+	}
+	_, err = rules.Compile(ruleData)
+	if err != nil {
+		return err
+	}
+
+	ruleData, err = s.db.CreateRule(context.Background(), creationParams)
+	if err != nil {
+		return fmt.Errorf("Error creating the rule: %v", err)
+	}
+	fmt.Printf("\nRule %v created for user <%v>. Run 'rules apply' to apply it to stored posts.\n", ruleData.ID, userData.Name)
+	return nil
+}
+
+func listRules(s *state, userData database.User) error {
+	ruleList, err := s.db.GetRulesForUser(context.Background(), userData.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting the rules: %v", err)
+	}
+
+	fmt.Printf("\nUser <%v> has these rules:\n", userData.Name)
+	for _, ruleData := range ruleList {
+		conditions := []string{}
+		if ruleData.FeedUrl.Valid {
+			conditions = append(conditions, fmt.Sprintf("feed %v", ruleData.FeedUrl.String))
+		}
+		if ruleData.TitlePattern.Valid {
+			conditions = append(conditions, fmt.Sprintf("title ~ /%v/", ruleData.TitlePattern.String))
+		}
+		if ruleData.DescriptionPattern.Valid {
+			conditions = append(conditions, fmt.Sprintf("description ~ /%v/", ruleData.DescriptionPattern.String))
+		}
+		if ruleData.Author.Valid {
+			conditions = append(conditions, fmt.Sprintf("author contains '%v'", ruleData.Author.String))
+		}
+		if ruleData.Category.Valid {
+			conditions = append(conditions, fmt.Sprintf("category '%v'", ruleData.Category.String))
+		}
+		action := ruleData.Action
+		if ruleData.Tag.Valid {
+			action = fmt.Sprintf("%v '%v'", action, ruleData.Tag.String)
+		}
+		fmt.Printf("\t- %v: %v when %v\n", ruleData.ID, action, strings.Join(conditions, " and "))
+	}
+	return nil
+}
+
+func deleteRule(s *state, arguments []string, userData database.User) error {
+	if len(arguments) < 1 {
+		return fmt.Errorf("Error: expected 1 argument (id), and found %v", len(arguments))
+	}
+	ruleID, err := uuid.Parse(arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error parsing the rule id: %v", err)
+	}
+
+	deleteParams := database.DeleteRuleParams {
+		ID: ruleID,
+		UserID: userData.ID,
+	}
+	deleted, err := s.db.DeleteRule(context.Background(), deleteParams)
+	if err != nil {
+		return fmt.Errorf("Error deleting the rule: %v", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("Error: user <%v> has no rule %v", userData.Name, ruleID)
+	}
+
+	fmt.Printf("\nRule %v deleted.\n", ruleID)
+	return nil
+}
+
+// applyRulesRetroactively runs the user's rules over every stored post
+// in the feeds they follow.
+func applyRulesRetroactively(s *state, userData database.User) error {
+	ruleList, err := s.db.GetRulesForUser(context.Background(), userData.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting the rules: %v", err)
+	}
+	matchers := []*rules.Matcher{}
+	for _, ruleData := range ruleList {
+		matcher, err := rules.Compile(database.Rule {
+			ID: ruleData.ID,
+			UserID: ruleData.UserID,
+			FeedID: ruleData.FeedID,
+			TitlePattern: ruleData.TitlePattern,
+			DescriptionPattern: ruleData.DescriptionPattern,
+			Author: ruleData.Author,
+			Category: ruleData.Category,
+			Action: ruleData.Action,
+			Tag: ruleData.Tag,
+		})
+		if err != nil {
+			return err
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	posts, err := s.db.GetAllPostsForUser(context.Background(), userData.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting posts for user: %v", err)
+	}
+
+	applied := 0
+	for _, post := range posts {
+		for _, matcher := range matchers {
+			if !matcher.Matches(post) {
+				continue
+			}
+			err = applyRuleAction(s, matcher.Rule, post)
+			if err != nil {
+				return err
+			}
+			applied++
+		}
+	}
+	fmt.Printf("\nApplied %v rule actions over %v posts.\n", applied, len(posts))
+	return nil
+}
+
+// applyRules runs the rules of every user following the post's feed
+// against a newly stored post, and returns the users who muted it.
+func applyRules(s *state, post database.Post) (map[uuid.UUID]bool, error) {
+	ruleList, err := s.db.GetRulesForFeed(context.Background(), post.FeedID)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting the rules for the feed: %v", err)
+	}
+	mutedFor := map[uuid.UUID]bool{}
+	for _, ruleData := range ruleList {
+		matcher, err := rules.Compile(ruleData)
+		if err != nil {
+			s.logger.Warn("Skipping rule", "rule_id", ruleData.ID, "error", err)
+			continue
+		}
+		if !matcher.Matches(post) {
+			continue
+		}
+		err = applyRuleAction(s, ruleData, post)
+		if err != nil {
+			return nil, err
+		}
+		if ruleData.Action == rules.ActionMute {
+			mutedFor[ruleData.UserID] = true
+		}
+	}
+	return mutedFor, nil
+}
+
+func applyRuleAction(s *state, ruleData database.Rule, post database.Post) error {
+	var err error
+	switch ruleData.Action {
+	case rules.ActionMute:
+		err = s.db.SetPostMuted(context.Background(), database.SetPostMutedParams {
+			UserID: ruleData.UserID,
+			PostID: post.ID,
+			Muted: true,
+			UpdatedAt: time.Now(),
+		})
+	case rules.ActionMarkRead:
+		err = s.db.SetPostRead(context.Background(), database.SetPostReadParams {
+			UserID: ruleData.UserID,
+			PostID: post.ID,
+			Read: true,
+			UpdatedAt: time.Now(),
+		})
+	case rules.ActionStar:
+		err = s.db.SetPostStarred(context.Background(), database.SetPostStarredParams {
+			UserID: ruleData.UserID,
+			PostID: post.ID,
+			Starred: true,
+			UpdatedAt: time.Now(),
+		})
+	case rules.ActionTag:
+		err = s.db.AddPostTag(context.Background(), database.AddPostTagParams {
+			UserID: ruleData.UserID,
+			PostID: post.ID,
+			Tag: ruleData.Tag.String,
+			CreatedAt: time.Now(),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("Error applying rule %v to post '%v': %v", ruleData.ID, post.Title, err)
+	}
+	return nil
+}
+
+func optionalString(value string) sql.NullString {
+	return sql.NullString{
+		String: value,
+		Valid: value != "",
+	}
+}
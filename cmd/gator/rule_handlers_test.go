@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+)
+
+func TestMutedPostsSendNoAlertsOrWebhooks(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	bob := createTestUser(t, s, "bob")
+	feed := newFeedServer(t, testItem{title: "Sponsored: buy now", link: "http://example.com/1", pubDate: time.Now()})
+	receiver, received := newHookReceiver(t)
+	addTestFeed(t, s, alice, "News", feed.URL+"/feed.xml")
+	_, err := run(t, s, bob, handlerFollow, feed.URL+"/feed.xml")
+	if err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	for _, user := range []database.User{alice, bob} {
+		_, err = run(t, s, user, handlerAlert, "add", "buy")
+		if err != nil {
+			t.Fatalf("alert add: %v", err)
+		}
+		_, err = run(t, s, user, handlerWebhooks, "add", receiver.URL+"/"+user.Name)
+		if err != nil {
+			t.Fatalf("webhooks add: %v", err)
+		}
+	}
+	// Rule patterns ignore case, as alert patterns do.
+	_, err = run(t, s, alice, handlerRules, "add", "mute", "--title", "^sponsored")
+	if err != nil {
+		t.Fatalf("rules add: %v", err)
+	}
+
+	err = scrape(t, s)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	err = deliverWebhooks(s, time.Now())
+	if err != nil {
+		t.Fatalf("delivering: %v", err)
+	}
+
+	for _, test := range []struct {
+		user   database.User
+		events int
+	}{
+		{alice, 0},
+		{bob, 1},
+	} {
+		events, err := s.db.GetAlertEventsForUser(context.Background(), database.GetAlertEventsForUserParams{UserID: test.user.ID, Limit: 10})
+		if err != nil {
+			t.Fatalf("getting the alert events: %v", err)
+		}
+		if len(events) != test.events {
+			t.Errorf("%v got %v alerts, want %v", test.user.Name, len(events), test.events)
+		}
+		if deliveries := webhookDeliveries(t, s, test.user); len(deliveries) != test.events {
+			t.Errorf("%v got %v webhook deliveries, want %v", test.user.Name, len(deliveries), test.events)
+		}
+	}
+	if len(received()) != 1 {
+		t.Errorf("received %v webhooks, want only bob's", len(received()))
+	}
+}
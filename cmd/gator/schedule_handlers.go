@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"slices"
+	"time"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/schedule"
+)
+
+var intervalSources = map[string]string{
+	schedule.SourceOverride: "set with editfeed --refresh",
+	schedule.SourceFeed: "asked for by the feed",
+	schedule.SourceLearned: "learned from its posts",
+	schedule.SourceDefault: "the default",
+	schedule.SourceMinimum: "raised to the minimum",
+	schedule.SourceMaximum: "lowered to the maximum",
+}
+
+func handlerSchedule(s *state, cmd command) error {
+	feeds, err := s.db.GetAllFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("Error getting the feeds: %v", err)
+	}
+	if len(feeds) == 0 {
+		fmt.Println("\nThere are no feeds to fetch.")
+		return nil
+	}
+
+	// Feeds due now first, then by when they're due.
+	slices.SortStableFunc(feeds, func(a, b database.Feed) int {
+		switch {
+		case !a.NextFetchAt.Valid && !b.NextFetchAt.Valid:
+			return 0
+		case !a.NextFetchAt.Valid:
+			return -1
+		case !b.NextFetchAt.Valid:
+			return 1
+		}
+		return a.NextFetchAt.Time.Compare(b.NextFetchAt.Time)
+	})
+
+	now := time.Now()
+	fmt.Println("\nFeed schedule, next due first:")
+	for _, feedData := range feeds {
+		fmt.Printf("\n| %v |\n", feedData.Name)
+		fmt.Printf("URL: %v\n", feedData.Url)
+		fmt.Printf("Fetched every: %v\n", describeInterval(feedData))
+		fmt.Printf("Next fetch: %v\n", describeNextFetch(feedData, now))
+	}
+	return nil
+}
+
+func describeInterval(feedData database.Feed) string {
+	if feedData.RefreshIntervalSeconds.Valid {
+		interval := time.Duration(feedData.RefreshIntervalSeconds.Int64) * time.Second
+		return fmt.Sprintf("%v (%v)", interval, intervalSources[schedule.SourceOverride])
+	}
+	if !feedData.FetchIntervalSeconds.Valid {
+		return "not known until the next fetch"
+	}
+	interval := time.Duration(feedData.FetchIntervalSeconds.Int64) * time.Second
+	return fmt.Sprintf("%v (%v)", interval, intervalSources[feedData.FetchIntervalSource.String])
+}
+
+func describeNextFetch(feedData database.Feed, now time.Time) string {
+	if !feedData.LastFetchedAt.Valid {
+		return "due now, never fetched"
+	}
+	if !feedData.NextFetchAt.Valid {
+		return "due now"
+	}
+	next := feedData.NextFetchAt.Time
+	if !next.After(now) {
+		return fmt.Sprintf("due since %v", next.Local().Format(time.DateTime))
+	}
+	return fmt.Sprintf("%v (in %v)", next.Local().Format(time.DateTime), next.Sub(now).Round(time.Second))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"github.com/Mr-Rafael/gator/internal/fever"
+	"github.com/Mr-Rafael/gator/internal/greader"
+	"github.com/Mr-Rafael/gator/internal/live"
+	"github.com/Mr-Rafael/gator/internal/storage"
+)
+
+func handlerServe(s *state, cmd command) error {
+	address := "localhost:8080"
+	if len(cmd.Arguments) >= 1 {
+		address = cmd.Arguments[0]
+	}
+
+	hub := live.NewHub(s.logger)
+	go func() {
+		var err error
+		if s.store.Dialect == storage.SQLite {
+			err = hub.Poll(context.Background(), s.db, 2*time.Second)
+		} else {
+			err = hub.Listen(context.Background(), s.Configuration.DBURL)
+		}
+		if err != nil {
+			s.logger.Warn("Live updates are disabled", "error", err)
+		}
+	}()
+
+	greaderServer := greader.NewServer(s.db, s.logger)
+	mux := http.NewServeMux()
+	mux.Handle("/accounts/", greaderServer)
+	mux.Handle("/reader/", greaderServer)
+	mux.Handle("/api/greader.php/", http.StripPrefix("/api/greader.php", greaderServer))
+	mux.Handle("/fever/", fever.NewServer(s.db, s.logger))
+	mux.Handle("/events/posts", live.NewServer(s.db, hub, s.logger))
+
+	s.logger.Info("Serving the API", "address", address)
+	err := http.ListenAndServe(address, mux)
+	if err != nil {
+		return fmt.Errorf("Error running the API server: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"strings"
+	"time"
+	"github.com/Mr-Rafael/gator/internal/database"
+)
+
+func handlerTag(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 2 {
+		return fmt.Errorf("Error: expected 2 arguments (url, tag), and found %v", len(cmd.Arguments))
+	}
+	feedURL := cmd.Arguments[0]
+	tag := strings.TrimSpace(cmd.Arguments[1])
+	if tag == "" {
+		return fmt.Errorf("Error: the tag can't be empty")
+	}
+	// OPML separates the categories of a feed with commas and trims the
+	// slashes around them, so such tags wouldn't survive an export.
+	if strings.Contains(tag, ",") {
+		return fmt.Errorf("Error: the tag can't contain commas")
+	}
+	if strings.HasPrefix(tag, "/") || strings.HasSuffix(tag, "/") {
+		return fmt.Errorf("Error: the tag can't start or end with '/'")
+	}
+
+	follow, err := getFollowFromURL(s, userData, feedURL)
+	if err != nil {
+		return err
+	}
+
+	tagParams := database.AddFeedFollowTagParams {
+		FeedFollowID: follow.ID,
+		Tag: tag,
+		CreatedAt: time.Now(),
+	}
+	err = s.db.AddFeedFollowTag(context.Background(), tagParams)
+	if err != nil {
+		return fmt.Errorf("Error tagging the feed: %v", err)
+	}
+	fmt.Printf("\nFeed '%v' tagged as '%v' for user <%v>.\n", feedURL, tag, userData.Name)
+	return nil
+}
+
+func handlerUntag(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 2 {
+		return fmt.Errorf("Error: expected 2 arguments (url, tag), and found %v", len(cmd.Arguments))
+	}
+	feedURL := cmd.Arguments[0]
+	tag := cmd.Arguments[1]
+
+	follow, err := getFollowFromURL(s, userData, feedURL)
+	if err != nil {
+		return err
+	}
+
+	untagParams := database.RemoveFeedFollowTagParams {
+		FeedFollowID: follow.ID,
+		Tag: tag,
+	}
+	removed, err := s.db.RemoveFeedFollowTag(context.Background(), untagParams)
+	if err != nil {
+		return fmt.Errorf("Error untagging the feed: %v", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("Error: feed '%v' is not tagged as '%v'", feedURL, tag)
+	}
+	fmt.Printf("\nFeed '%v' is no longer tagged as '%v' for user <%v>.\n", feedURL, tag, userData.Name)
+	return nil
+}
+
+func getFollowFromURL(s *state, userData database.User, feedURL string) (database.FeedFollow, error) {
+	feedData, err := s.db.GetFeedFromURL(context.Background(), feedURL)
+	if err != nil {
+		return database.FeedFollow{}, fmt.Errorf("Error getting the feed data: %v", err)
+	}
+	followParams := database.GetFeedFollowParams {
+		UserID: userData.ID,
+		FeedID: feedData.ID,
+	}
+	follow, err := s.db.GetFeedFollow(context.Background(), followParams)
+	if err != nil {
+		return database.FeedFollow{}, fmt.Errorf("Error: user <%v> is not following '%v': %v", userData.Name, feedURL, err)
+	}
+	return follow, nil
+}
@@ -5,7 +5,10 @@ import (
 	"errors"
 	"context"
 	"github.com/google/uuid"
+	"strings"
 	"time"
+	"database/sql"
+	"github.com/Mr-Rafael/gator/internal/auth"
 	"github.com/Mr-Rafael/gator/internal/config"
 	"github.com/Mr-Rafael/gator/internal/database"
 )
@@ -35,11 +38,17 @@ func handlerRegister(s *state, cmd command) error {
 	}
 	
 	userName := cmd.Arguments[0]
+	userCount, err := s.db.CountUsers(context.Background())
+	if err != nil {
+		return fmt.Errorf("Error counting the users: %v", err)
+	}
 	creationParams := database.CreateUserParams {	
 		ID: uuid.New(),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Name: userName,
+		// The first user administers the install.
+		IsAdmin: userCount == 0,
 	}
 
 	userData, err := s.db.CreateUser(context.Background(), creationParams)
@@ -65,12 +74,204 @@ func handlerUsers(s *state, cmd command) error {
 	}
 
 	for _, userData := range usersData {
+		labels := ""
+		if userData.IsAdmin {
+			labels += " (admin)"
+		}
 		if userData.Name == currentUser {
-			fmt.Printf("\n* %v (current)", userData.Name)
-		} else {
-			fmt.Printf("\n* %v", userData.Name)
+			labels += " (current)"
 		}
+		fmt.Printf("\n* %v%v", userData.Name, labels)
 	}
 	fmt.Println()
 	return nil
-}
\ No newline at end of file
+}
+
+func handlerSetPassword(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("Error: expected 1 argument (password), and found 0")
+	}
+
+	password := cmd.Arguments[0]
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	updateParams := database.SetUserAPIPasswordParams {
+		ID: userData.ID,
+		ApiPasswordHash: sql.NullString{
+			String: passwordHash,
+			Valid: true,
+		},
+		FeverApiKey: sql.NullString{
+			String: auth.FeverAPIKey(userData.Name, password),
+			Valid: true,
+		},
+		UpdatedAt: time.Now(),
+	}
+	err = s.db.SetUserAPIPassword(context.Background(), updateParams)
+	if err != nil {
+		return fmt.Errorf("Error storing the API password: %v", err)
+	}
+
+	fmt.Printf("\nAPI password updated for user <%v>. Previously issued API tokens no longer work.\n", userData.Name)
+	return nil
+}
+func handlerRenameUser(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("Error: expected a new name (renameuser [user] <new-name>)")
+	}
+	targetUser := userData
+	newName := cmd.Arguments[0]
+	if len(cmd.Arguments) >= 2 {
+		var err error
+		targetUser, err = getManagedUser(s, userData, cmd.Arguments[0])
+		if err != nil {
+			return err
+		}
+		newName = cmd.Arguments[1]
+	}
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("Error: the new name can't be empty")
+	}
+
+	renameParams := database.RenameUserParams {
+		ID: targetUser.ID,
+		Name: newName,
+		UpdatedAt: time.Now(),
+	}
+	err := s.db.RenameUser(context.Background(), renameParams)
+	if err != nil {
+		return fmt.Errorf("Error renaming the user: %v", err)
+	}
+	if targetUser.ID == userData.ID {
+		err = config.SetUser(newName)
+		if err != nil {
+			return fmt.Errorf("Error: <%v> was renamed to <%v>, but the config couldn't be updated. Log in as <%v>: %v", targetUser.Name, newName, newName, err)
+		}
+		updateConfig(s)
+	}
+
+	fmt.Printf("\nUser <%v> is now <%v>.\n", targetUser.Name, newName)
+	if targetUser.ApiPasswordHash.Valid {
+		fmt.Println("The API password was cleared, as it was tied to the old name. Set a new one with 'setpassword'.")
+	}
+	return nil
+}
+
+func handlerDeleteUser(s *state, cmd command, userData database.User) error {
+	targetUser := userData
+	if len(cmd.Arguments) >= 1 && cmd.Arguments[0] != "--yes" {
+		var err error
+		targetUser, err = getManagedUser(s, userData, cmd.Arguments[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	if targetUser.IsAdmin {
+		admins, err := s.db.CountAdmins(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error counting the admins: %v", err)
+		}
+		users, err := s.db.CountUsers(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error counting the users: %v", err)
+		}
+		if admins == 1 && users > 1 {
+			return fmt.Errorf("Error: <%v> is the only admin. Promote another user first", targetUser.Name)
+		}
+	}
+
+	usage, err := s.db.GetUserUsage(context.Background(), targetUser.ID)
+	if err != nil {
+		return fmt.Errorf("Error summarizing the user's data: %v", err)
+	}
+	fmt.Printf("\nDeleting user <%v> will also delete:\n", targetUser.Name)
+	fmt.Printf("\t- %v feed follows\n", usage.Follows)
+	fmt.Printf("\t- %v read/starred post states\n", usage.PostStates)
+	fmt.Printf("\t- %v post tags\n", usage.PostTags)
+	fmt.Printf("\t- %v webhooks\n", usage.Webhooks)
+	fmt.Printf("\t- %v rules\n", usage.Rules)
+	fmt.Printf("\t- %v alerts\n", usage.Alerts)
+	fmt.Printf("The %v feeds they added are kept for their other followers, without an owner.\n", usage.OwnedFeeds)
+	if !hasFlag(cmd.Arguments, "--yes") && !confirm(fmt.Sprintf("Delete user <%v>?", targetUser.Name)) {
+		fmt.Println("\nNothing was deleted.")
+		return nil
+	}
+
+	err = s.db.DeleteUser(context.Background(), targetUser.ID)
+	if err != nil {
+		return fmt.Errorf("Error deleting the user: %v", err)
+	}
+	if targetUser.ID == userData.ID {
+		config.SetUser("")
+		updateConfig(s)
+	}
+	fmt.Printf("\nUser <%v> was deleted.\n", targetUser.Name)
+	return nil
+}
+
+func handlerPromote(s *state, cmd command, userData database.User) error {
+	return setAdmin(s, cmd, userData, true)
+}
+
+func handlerDemote(s *state, cmd command, userData database.User) error {
+	return setAdmin(s, cmd, userData, false)
+}
+
+func setAdmin(s *state, cmd command, userData database.User, isAdmin bool) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected 1 argument (user), and found %v", len(cmd.Arguments))
+	}
+	if !userData.IsAdmin {
+		return fmt.Errorf("Error: only admins can change who is an admin")
+	}
+	targetUser, err := s.db.GetUser(context.Background(), cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error getting the user: %v", err)
+	}
+	if !isAdmin && targetUser.IsAdmin {
+		admins, err := s.db.CountAdmins(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error counting the admins: %v", err)
+		}
+		if admins == 1 {
+			return fmt.Errorf("Error: <%v> is the only admin", targetUser.Name)
+		}
+	}
+
+	adminParams := database.SetUserAdminParams {
+		ID: targetUser.ID,
+		IsAdmin: isAdmin,
+		UpdatedAt: time.Now(),
+	}
+	err = s.db.SetUserAdmin(context.Background(), adminParams)
+	if err != nil {
+		return fmt.Errorf("Error updating the user: %v", err)
+	}
+	if isAdmin {
+		fmt.Printf("\nUser <%v> is now an admin.\n", targetUser.Name)
+	} else {
+		fmt.Printf("\nUser <%v> is no longer an admin.\n", targetUser.Name)
+	}
+	return nil
+}
+
+// getManagedUser looks up another user for the current one to manage,
+// which only admins may do.
+func getManagedUser(s *state, userData database.User, userName string) (database.User, error) {
+	if userName == userData.Name {
+		return userData, nil
+	}
+	if !userData.IsAdmin {
+		return database.User{}, fmt.Errorf("Error: only admins can manage other users")
+	}
+	targetUser, err := s.db.GetUser(context.Background(), userName)
+	if err != nil {
+		return database.User{}, fmt.Errorf("Error getting the user: %v", err)
+	}
+	return targetUser, nil
+}
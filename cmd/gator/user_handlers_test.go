@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRenameUserRejectsBlankNames(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+
+	for _, name := range []string{"", "  "} {
+		_, err := run(t, s, alice, handlerRenameUser, name)
+		if err == nil {
+			t.Errorf("renamed alice to %q", name)
+		}
+	}
+	_, err := s.db.GetUser(context.Background(), "alice")
+	if err != nil {
+		t.Errorf("alice is gone after the rejected renames: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"context"
+	"time"
+	"strconv"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"github.com/google/uuid"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/rss"
+	"github.com/Mr-Rafael/gator/internal/webhooks"
+)
+
+const webhooksUsage = "webhooks add <url> [feed url] [secret] | list | delete <id> | deliveries [limit] | deliver"
+
+func handlerWebhooks(s *state, cmd command, userData database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return fmt.Errorf("Error: expected a subcommand (%v)", webhooksUsage)
+	}
+	subcommand := cmd.Arguments[0]
+	arguments := cmd.Arguments[1:]
+
+	switch subcommand {
+	case "add":
+		return addWebhook(s, arguments, userData)
+	case "list":
+		return listWebhooks(s, userData)
+	case "delete":
+		return deleteWebhook(s, arguments, userData)
+	case "deliveries":
+		return listWebhookDeliveries(s, arguments, userData)
+	case "deliver":
+		return deliverWebhooks(s, time.Now())
+	}
+	return fmt.Errorf("Error: unknown subcommand '%v' (%v)", subcommand, webhooksUsage)
+}
+
+func addWebhook(s *state, arguments []string, userData database.User) error {
+	if len(arguments) < 1 {
+		return fmt.Errorf("Error: expected at least 1 argument (url), and found %v", len(arguments))
+	}
+	_, err := rss.ParseHTTPURL(arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error: invalid webhook url: %v", err)
+	}
+
+	creationParams := database.CreateWebhookParams {
+		ID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID: userData.ID,
+		Url: arguments[0],
+		Secret: webhooks.NewSecret(),
+	}
+	if len(arguments) >= 2 && arguments[1] != "" {
+		feedData, err := s.db.GetFeedFromURL(context.Background(), arguments[1])
+		if err != nil {
+			return fmt.Errorf("Error getting the feed data: %v", err)
+		}
+		creationParams.FeedID = uuid.NullUUID{UUID: feedData.ID, Valid: true}
+	}
+	if len(arguments) >= 3 {
+		creationParams.Secret = arguments[2]
+	}
+
+	webhookData, err := s.db.CreateWebhook(context.Background(), creationParams)
+	if err != nil {
+		return fmt.Errorf("Error creating the webhook: %v", err)
+	}
+
+	fmt.Printf("\nWebhook %v created for user <%v>.\n", webhookData.ID, userData.Name)
+	fmt.Printf("Payloads are signed in the %v header with secret: %v\n", webhooks.SignatureHeader, webhookData.Secret)
+	return nil
+}
+
+func listWebhooks(s *state, userData database.User) error {
+	webhookList, err := s.db.GetWebhooksForUser(context.Background(), userData.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting the webhooks: %v", err)
+	}
+
+	fmt.Printf("\nUser <%v> has these webhooks:\n", userData.Name)
+	for _, webhookData := range webhookList {
+		feedFilter := "all followed feeds"
+		if webhookData.FeedUrl.Valid {
+			feedFilter = webhookData.FeedUrl.String
+		}
+		fmt.Printf("\t- %v %v (%v)\n", webhookData.ID, webhookData.Url, feedFilter)
+	}
+	return nil
+}
+
+func deleteWebhook(s *state, arguments []string, userData database.User) error {
+	if len(arguments) < 1 {
+		return fmt.Errorf("Error: expected 1 argument (id), and found %v", len(arguments))
+	}
+	webhookID, err := uuid.Parse(arguments[0])
+	if err != nil {
+		return fmt.Errorf("Error parsing the webhook id: %v", err)
+	}
+
+	deleteParams := database.DeleteWebhookParams {
+		ID: webhookID,
+		UserID: userData.ID,
+	}
+	deleted, err := s.db.DeleteWebhook(context.Background(), deleteParams)
+	if err != nil {
+		return fmt.Errorf("Error deleting the webhook: %v", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("Error: user <%v> has no webhook %v", userData.Name, webhookID)
+	}
+
+	fmt.Printf("\nWebhook %v deleted.\n", webhookID)
+	return nil
+}
+
+func listWebhookDeliveries(s *state, arguments []string, userData database.User) error {
+	limit := 20
+	if len(arguments) >= 1 {
+		var err error
+		limit, err = strconv.Atoi(arguments[0])
+		if err != nil {
+			return fmt.Errorf("Expected a numeric parameter for command. %v", err)
+		}
+	}
+
+	getDeliveriesParams := database.GetWebhookDeliveriesForUserParams {
+		UserID: userData.ID,
+		Limit: int32(limit),
+	}
+	deliveries, err := s.db.GetWebhookDeliveriesForUser(context.Background(), getDeliveriesParams)
+	if err != nil {
+		return fmt.Errorf("Error getting the webhook deliveries: %v", err)
+	}
+
+	fmt.Printf("\nLatest webhook deliveries for user <%v>:\n", userData.Name)
+	for _, delivery := range deliveries {
+		fmt.Printf("\n| %v | %v |\n", delivery.Status, delivery.PostTitle)
+		fmt.Printf("Webhook: %v\n", delivery.WebhookUrl)
+		fmt.Printf("Attempts: %v\n", delivery.Attempts)
+		if delivery.LastStatusCode.Valid {
+			fmt.Printf("Last status code: %v\n", delivery.LastStatusCode.Int32)
+		}
+		if delivery.LastError.Valid {
+			fmt.Printf("Last error: %v\n", delivery.LastError.String)
+		}
+		if delivery.Status == "pending" {
+			fmt.Printf("Next attempt at: %v\n", delivery.NextAttemptAt)
+		}
+	}
+	return nil
+}
+
+// enqueueWebhooks queues a delivery of post to every webhook whose owner
+// follows the post's feed, unless the owner is in mutedFor.
+func enqueueWebhooks(s *state, post database.Post, mutedFor map[uuid.UUID]bool) error {
+	webhookList, err := s.db.GetWebhooksForFeed(context.Background(), post.FeedID)
+	if err != nil {
+		return fmt.Errorf("Error getting the webhooks for the feed: %v", err)
+	}
+	for _, webhookData := range webhookList {
+		if mutedFor[webhookData.UserID] {
+			continue
+		}
+		deliveryParams := database.CreateWebhookDeliveryParams {
+			ID: uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			WebhookID: webhookData.ID,
+			PostID: post.ID,
+		}
+		err = s.db.CreateWebhookDelivery(context.Background(), deliveryParams)
+		if err != nil {
+			return fmt.Errorf("Error queueing the webhook delivery: %v", err)
+		}
+	}
+	return nil
+}
+
+// hookLock keeps the workers from building the hook client twice.
+var hookLock sync.Mutex
+
+// hookClient returns the client webhooks and alert webhooks are POSTed
+// with. Users choose where those go, so it keeps to the same addresses as
+// the feed fetches.
+func hookClient(s *state) (*http.Client, error) {
+	hookLock.Lock()
+	defer hookLock.Unlock()
+	if s.hookClient == nil {
+		transport, err := rss.NewTransport(s.Configuration.Fetch)
+		if err != nil {
+			return nil, err
+		}
+		s.hookClient = &http.Client{Transport: transport, Timeout: webhooks.Timeout}
+	}
+	return s.hookClient, nil
+}
+
+// deliverWebhooks sends every delivery that is due at now, scheduling a
+// retry for the ones that fail until they run out of attempts.
+func deliverWebhooks(s *state, now time.Time) error {
+	client, err := hookClient(s)
+	if err != nil {
+		return err
+	}
+	getDueParams := database.GetDueWebhookDeliveriesParams {
+		Now: now,
+		MaxDeliveries: 100,
+	}
+	deliveries, err := s.db.GetDueWebhookDeliveries(context.Background(), getDueParams)
+	if err != nil {
+		return fmt.Errorf("Error getting the pending webhook deliveries: %v", err)
+	}
+
+	for _, delivery := range deliveries {
+		event := webhooks.PostEvent {
+			Event: "post",
+			DeliveryID: delivery.ID,
+			ItemID: delivery.ItemID,
+			Title: delivery.Title,
+			URL: delivery.PostUrl,
+			Description: delivery.Description.String,
+			Feed: webhooks.Feed {
+				Name: delivery.FeedName,
+				URL: delivery.FeedUrl,
+			},
+		}
+		if delivery.PublishedAt.Valid {
+			event.PublishedAt = &delivery.PublishedAt.Time
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("Error encoding the webhook payload: %v", err)
+		}
+
+		statusCode, sendErr := webhooks.Send(context.Background(), client, delivery.WebhookUrl, delivery.Secret, delivery.ID, body)
+		lastStatusCode := sql.NullInt32{
+			Int32: int32(statusCode),
+			Valid: statusCode != 0,
+		}
+		if sendErr == nil {
+			deliveredParams := database.MarkWebhookDeliveryDeliveredParams {
+				ID: delivery.ID,
+				LastStatusCode: lastStatusCode,
+				DeliveredAt: time.Now(),
+			}
+			err = s.db.MarkWebhookDeliveryDelivered(context.Background(), deliveredParams)
+			if err != nil {
+				return fmt.Errorf("Error recording the webhook delivery: %v", err)
+			}
+			continue
+		}
+
+		s.logger.Warn("Webhook delivery failed", "delivery_id", delivery.ID, "webhook_url", delivery.WebhookUrl, "error", sendErr)
+		attempts := int(delivery.Attempts) + 1
+		status := "pending"
+		if attempts >= webhooks.MaxAttempts {
+			status = "failed"
+		}
+		failedParams := database.MarkWebhookDeliveryFailedParams {
+			ID: delivery.ID,
+			Status: status,
+			LastStatusCode: lastStatusCode,
+			LastError: sql.NullString{
+				String: sendErr.Error(),
+				Valid: true,
+			},
+			NextAttemptAt: now.Add(webhooks.RetryDelay(attempts)),
+			UpdatedAt: time.Now(),
+		}
+		err = s.db.MarkWebhookDeliveryFailed(context.Background(), failedParams)
+		if err != nil {
+			return fmt.Errorf("Error recording the webhook delivery: %v", err)
+		}
+	}
+	return nil
+}
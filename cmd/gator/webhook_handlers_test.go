@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/webhooks"
+)
+
+type receivedHook struct {
+	body      []byte
+	signature string
+}
+
+// newHookReceiver records the webhooks POSTed to it, answering each with
+// the next of statuses, and 200 once they run out.
+func newHookReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedHook) {
+	t.Helper()
+	var mu sync.Mutex
+	received := []receivedHook{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, receivedHook{body: body, signature: r.Header.Get(webhooks.SignatureHeader)})
+		status := http.StatusOK
+		if len(received) <= len(statuses) {
+			status = statuses[len(received)-1]
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []receivedHook {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedHook{}, received...)
+	}
+}
+
+func webhookDeliveries(t *testing.T, s *state, userData database.User) []database.GetWebhookDeliveriesForUserRow {
+	t.Helper()
+	deliveries, err := s.db.GetWebhookDeliveriesForUser(context.Background(), database.GetWebhookDeliveriesForUserParams{UserID: userData.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("getting the deliveries: %v", err)
+	}
+	return deliveries
+}
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	feed := newFeedServer(t, testItem{title: "Hooked", link: "http://example.com/1", pubDate: time.Now()})
+	receiver, received := newHookReceiver(t, http.StatusInternalServerError)
+	addTestFeed(t, s, alice, "News", feed.URL+"/feed.xml")
+	_, err := run(t, s, alice, handlerWebhooks, "add", receiver.URL+"/hook", "", "s3cret")
+	if err != nil {
+		t.Fatalf("webhooks add: %v", err)
+	}
+	err = scrape(t, s)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+
+	now := time.Now()
+	err = deliverWebhooks(s, now)
+	if err != nil {
+		t.Fatalf("delivering: %v", err)
+	}
+	hooks := received()
+	if len(hooks) != 1 {
+		t.Fatalf("received %v webhooks, want 1", len(hooks))
+	}
+	if !strings.Contains(string(hooks[0].body), `"title":"Hooked"`) {
+		t.Errorf("received the payload %s", hooks[0].body)
+	}
+	if hooks[0].signature != webhooks.Sign("s3cret", hooks[0].body) {
+		t.Errorf("received the signature %q, which doesn't match the payload", hooks[0].signature)
+	}
+	delivery := webhookDeliveries(t, s, alice)[0]
+	if delivery.Status != "pending" || delivery.Attempts != 1 || delivery.LastStatusCode.Int32 != http.StatusInternalServerError {
+		t.Errorf("recorded the failed delivery as %+v", delivery)
+	}
+	if !delivery.NextAttemptAt.Equal(now.Add(webhooks.RetryDelay(1))) {
+		t.Errorf("retrying at %v, want %v", delivery.NextAttemptAt, now.Add(webhooks.RetryDelay(1)))
+	}
+
+	// Nothing is sent again until the retry is due.
+	err = deliverWebhooks(s, now.Add(webhooks.RetryDelay(1)/2))
+	if err != nil {
+		t.Fatalf("delivering: %v", err)
+	}
+	if len(received()) != 1 {
+		t.Fatal("retried the delivery before it was due")
+	}
+	err = deliverWebhooks(s, now.Add(webhooks.RetryDelay(1)))
+	if err != nil {
+		t.Fatalf("delivering: %v", err)
+	}
+	hooks = received()
+	if len(hooks) != 2 || string(hooks[1].body) != string(hooks[0].body) {
+		t.Fatalf("received %v webhooks, want the same payload twice", len(hooks))
+	}
+	delivery = webhookDeliveries(t, s, alice)[0]
+	if delivery.Status != "delivered" || !delivery.DeliveredAt.Valid {
+		t.Errorf("recorded the retried delivery as %+v", delivery)
+	}
+}
+
+func TestWebhooksKeepAwayFromInternalAddresses(t *testing.T) {
+	s, alice := newTestState(t, "alice")
+	feed := newFeedServer(t, testItem{title: "Hooked", link: "http://example.com/1", pubDate: time.Now()})
+	receiver, received := newHookReceiver(t)
+	addTestFeed(t, s, alice, "News", feed.URL+"/feed.xml")
+
+	_, err := run(t, s, alice, handlerWebhooks, "add", "file:///etc/passwd")
+	if err == nil {
+		t.Error("added a webhook with a file url")
+	}
+	_, err = run(t, s, alice, handlerWebhooks, "add", receiver.URL+"/hook")
+	if err != nil {
+		t.Fatalf("webhooks add: %v", err)
+	}
+	err = scrape(t, s)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	// Unlike the test state's, this config doesn't allow localhost.
+	s.Configuration.Fetch = config.FetchConfig{}
+	s.hookClient = nil
+
+	err = deliverWebhooks(s, time.Now())
+	if err != nil {
+		t.Fatalf("delivering: %v", err)
+	}
+	if len(received()) != 0 {
+		t.Fatal("delivered a webhook to 127.0.0.1")
+	}
+	delivery := webhookDeliveries(t, s, alice)[0]
+	if !strings.Contains(delivery.LastError.String, "internal address 127.0.0.1") {
+		t.Errorf("recorded the delivery as %+v", delivery)
+	}
+}
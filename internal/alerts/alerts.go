@@ -0,0 +1,151 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/rss"
+	"github.com/google/uuid"
+)
+
+const (
+	NotifierStdout  = "stdout"
+	NotifierExec    = "exec"
+	NotifierWebhook = "webhook"
+)
+
+var Notifiers = []string{NotifierStdout, NotifierExec, NotifierWebhook}
+
+// Notification describes an alert that fired. It is what the exec notifier
+// writes to the command's stdin and the webhook notifier POSTs.
+type Notification struct {
+	AlertID  uuid.UUID `json:"alert_id"`
+	Pattern  string    `json:"pattern"`
+	UserName string    `json:"user_name"`
+	Post     Post      `json:"post"`
+}
+
+type Post struct {
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	Author      string     `json:"author"`
+	PublishedAt *time.Time `json:"published_at"`
+	FeedName    string     `json:"feed_name"`
+	FeedURL     string     `json:"feed_url"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Compile builds the matcher for an alert pattern. Patterns are regular
+// expressions matched case-insensitively.
+func Compile(pattern string) (*regexp.Regexp, error) {
+	matcher, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Error compiling the alert pattern: %v", err)
+	}
+	return matcher, nil
+}
+
+// NewNotifier returns the notifier for an alert. Exec targets name a
+// command from the config file rather than a command line, so users of a
+// shared database cannot run arbitrary commands on the aggregator's host.
+// Webhooks are POSTed with client, which should keep them away from
+// internal addresses the way feed fetches are.
+func NewNotifier(kind, target string, commands map[string]string, client *http.Client) (Notifier, error) {
+	switch kind {
+	case NotifierStdout:
+		return StdoutNotifier{Out: os.Stdout}, nil
+	case NotifierExec:
+		command, ok := commands[target]
+		if !ok {
+			return nil, fmt.Errorf("Error: '%v' is not one of the alert_commands in the configuration", target)
+		}
+		return ExecNotifier{Command: command}, nil
+	case NotifierWebhook:
+		if target == "" {
+			return nil, fmt.Errorf("Error: the webhook notifier needs a URL")
+		}
+		_, err := rss.ParseHTTPURL(target)
+		if err != nil {
+			return nil, fmt.Errorf("Error: invalid webhook url: %v", err)
+		}
+		return WebhookNotifier{URL: target, Client: client}, nil
+	}
+	return nil, fmt.Errorf("Error: unknown notifier '%v'", kind)
+}
+
+type StdoutNotifier struct {
+	Out io.Writer
+}
+
+func (n StdoutNotifier) Notify(ctx context.Context, notification Notification) error {
+	_, err := fmt.Fprintf(n.Out, "\n[ALERT /%v/ for <%v>] %v\n%v\n",
+		notification.Pattern, notification.UserName, notification.Post.Title, notification.Post.URL)
+	return err
+}
+
+// ExecNotifier runs a command through the shell with the notification
+// JSON on its stdin.
+type ExecNotifier struct {
+	Command string
+}
+
+func (n ExecNotifier) Notify(ctx context.Context, notification Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", n.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	// Killing the shell leaves the commands it started holding the output
+	// open, so stop waiting for them shortly after.
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Alert command failed: %v: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("Failed to generate the request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to reach the alert webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Alert webhook answered with status: %v", resp.Status)
+	}
+	return nil
+}
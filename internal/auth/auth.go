@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+)
+
+const (
+	hashScheme     = "pbkdf2-sha256"
+	hashIterations = 600000
+	saltLength     = 16
+	keyLength      = 32
+)
+
+// HashPassword derives the value stored in users.api_password_hash.
+// The format is "pbkdf2-sha256$<iterations>$<salt>$<key>".
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("Error generating the password salt: %v", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, hashIterations, keyLength)
+	if err != nil {
+		return "", fmt.Errorf("Error hashing the password: %v", err)
+	}
+	return fmt.Sprintf("%v$%v$%v$%v",
+		hashScheme,
+		hashIterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// CheckPassword reports whether password matches a hash produced by HashPassword.
+func CheckPassword(password, hash string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != hashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+// Token returns the API token handed out to clients after a successful login.
+// It is derived from the stored password hash, so setting a new password
+// revokes every token issued before.
+func Token(userName, passwordHash string) string {
+	mac := hmac.New(sha256.New, []byte(passwordHash))
+	mac.Write([]byte(userName))
+	return userName + "/" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// TokenUser extracts the user name a token was issued for.
+func TokenUser(token string) (string, bool) {
+	i := strings.LastIndex(token, "/")
+	if i <= 0 {
+		return "", false
+	}
+	return token[:i], true
+}
+
+// CheckToken reports whether token was issued for userName with passwordHash.
+func CheckToken(token, userName, passwordHash string) bool {
+	return hmac.Equal([]byte(token), []byte(Token(userName, passwordHash)))
+}
+
+// UserFromToken looks up the user a token was issued for and checks the
+// token is still valid for their current password.
+func UserFromToken(ctx context.Context, db database.Querier, token string) (database.User, error) {
+	userName, ok := TokenUser(token)
+	if !ok {
+		return database.User{}, errors.New("malformed token")
+	}
+	userData, err := db.GetUser(ctx, userName)
+	if err != nil {
+		return database.User{}, err
+	}
+	if !userData.ApiPasswordHash.Valid || !CheckToken(token, userData.Name, userData.ApiPasswordHash.String) {
+		return database.User{}, errors.New("invalid token")
+	}
+	return userData, nil
+}
+
+// FeverAPIKey returns the api_key Fever clients send, which the Fever API
+// defines as md5("<email>:<password>"). Gator uses the user name as email.
+func FeverAPIKey(userName, password string) string {
+	sum := md5.Sum([]byte(userName + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
@@ -11,6 +11,83 @@ const configFileName = ".gatorconfig.json"
 type Config struct {
 	DBURL string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	SMTP SMTPConfig `json:"smtp"`
+	AlertCommands map[string]string `json:"alert_commands,omitempty"`
+	Retention RetentionConfig `json:"retention"`
+	Metrics MetricsConfig `json:"metrics"`
+	Schedule ScheduleConfig `json:"schedule"`
+	Fetch FetchConfig `json:"fetch"`
+}
+
+// SMTPConfig is the mail server digests are sent through. Username and
+// Password may be left empty for servers that do not require auth.
+type SMTPConfig struct {
+	Host string `json:"host"`
+	Port int `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From string `json:"from"`
+}
+
+// RetentionConfig is the post retention policy for feeds that don't set
+// their own. MaxAge is a duration such as "720h"; empty or zero limits
+// keep posts forever. Interval is how often agg prunes, hourly by default.
+// MaxFetchesPerFeed caps the fetch log of each feed, 100 by default.
+type RetentionConfig struct {
+	MaxAge string `json:"max_age,omitempty"`
+	MaxPostsPerFeed int `json:"max_posts_per_feed,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	MaxFetchesPerFeed int `json:"max_fetches_per_feed,omitempty"`
+}
+
+// MetricsConfig is where agg serves its Prometheus metrics. An empty
+// Listen address leaves the listener off. OverdueAfter is how long a feed
+// can stay due before it counts as overdue, an hour by default.
+type MetricsConfig struct {
+	Listen string `json:"listen,omitempty"`
+	OverdueAfter string `json:"overdue_after,omitempty"`
+}
+
+// ScheduleConfig is how often agg fetches feeds when nothing else says,
+// such as "30m", and the bounds of how often it fetches any feed the user
+// doesn't set an interval for. Empty means hourly, within 15m and 24h.
+type ScheduleConfig struct {
+	DefaultInterval string `json:"default_interval,omitempty"`
+	MinInterval string `json:"min_interval,omitempty"`
+	MaxInterval string `json:"max_interval,omitempty"`
+}
+
+// FetchConfig is how agg fetches feeds. PerHost limits the fetches from
+// every host, and Hosts overrides those limits for a host along with its
+// subdomains. The timeouts are durations such as "30s", 30s overall and
+// 10s to connect by default. Proxy is an http(s) url, and otherwise the
+// HTTP_PROXY/HTTPS_PROXY variables apply. CABundle is a PEM file trusted
+// on top of the system's certificates. UserAgent replaces the default
+// "gator/<version> (+<ContactURL>)". MaxRedirects is 10 by default.
+// MaxBodyBytes caps the size of a feed, 10 MiB by default. Fetches can't
+// reach loopback, private or link-local addresses, except for AllowHosts,
+// with their subdomains, and the AllowNetworks, such as "10.1.0.0/16".
+type FetchConfig struct {
+	PerHost HostLimitConfig `json:"per_host"`
+	Hosts map[string]HostLimitConfig `json:"hosts,omitempty"`
+	Timeout string `json:"timeout,omitempty"`
+	ConnectTimeout string `json:"connect_timeout,omitempty"`
+	Proxy string `json:"proxy,omitempty"`
+	CABundle string `json:"ca_bundle,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	ContactURL string `json:"contact_url,omitempty"`
+	MaxRedirects int `json:"max_redirects,omitempty"`
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+	AllowHosts []string `json:"allow_hosts,omitempty"`
+	AllowNetworks []string `json:"allow_networks,omitempty"`
+}
+
+// HostLimitConfig caps the fetches from a host running at once, and sets
+// the least time between starting them, such as "2s". Left out, they are
+// 2 and 1s, or the per_host values for an override.
+type HostLimitConfig struct {
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
+	MinDelay string `json:"min_delay,omitempty"`
 }
 
 func Read() (Config, error) {
@@ -50,7 +127,13 @@ func SetUser(userName string) error {
 		return fmt.Errorf("Error trying to transform config to json string: %v", err)
 	}
 
-	return os.WriteFile(filePath, jsonBytes, 0644)
+	// The file holds credentials such as the SMTP password, so it is kept
+	// private, including when an older gator created it readable to all.
+	err = os.WriteFile(filePath, jsonBytes, 0600)
+	if err != nil {
+		return fmt.Errorf("Error writing the config file: %v", err)
+	}
+	return os.Chmod(filePath, 0600)
 }
 
 func getConfigFilePath() (string, error) {
@@ -14,23 +14,15 @@ import (
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
-WITH inserted AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-    VALUES (
-        $1,
-        $2,
-        $3,
-        $4,
-        $5
-    )
-    RETURNING id, user_id, feed_id
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
 )
-SELECT i.id, i.user_id, feed_id, users.id, users.created_at, users.updated_at, users.name, feeds.id, feeds.name, url, feeds.user_id, feeds.created_at, feeds.updated_at, last_fetched_at
-FROM inserted i
-INNER JOIN users
-    ON i.user_id = users.id
-INNER JOIN feeds
-    ON i.feed_id = feeds.id
+RETURNING id, created_at, updated_at, user_id, feed_id, title
 `
 
 type CreateFeedFollowParams struct {
@@ -41,24 +33,7 @@ type CreateFeedFollowParams struct {
 	FeedID    uuid.UUID
 }
 
-type CreateFeedFollowRow struct {
-	ID            uuid.UUID
-	UserID        uuid.UUID
-	FeedID        uuid.UUID
-	ID_2          uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	ID_3          uuid.UUID
-	Name_2        string
-	Url           string
-	UserID_2      uuid.UUID
-	CreatedAt_2   time.Time
-	UpdatedAt_2   time.Time
-	LastFetchedAt sql.NullTime
-}
-
-func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
+func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (FeedFollow, error) {
 	row := q.db.QueryRowContext(ctx, createFeedFollow,
 		arg.ID,
 		arg.CreatedAt,
@@ -66,26 +41,28 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UserID,
 		arg.FeedID,
 	)
-	var i CreateFeedFollowRow
+	var i FeedFollow
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
-		&i.FeedID,
-		&i.ID_2,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.Name,
-		&i.ID_3,
-		&i.Name_2,
-		&i.Url,
-		&i.UserID_2,
-		&i.CreatedAt_2,
-		&i.UpdatedAt_2,
-		&i.LastFetchedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
 	)
 	return i, err
 }
 
+const deleteFeedFollowsForUser = `-- name: DeleteFeedFollowsForUser :exec
+DELETE FROM feed_follows
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteFeedFollowsForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeedFollowsForUser, userID)
+	return err
+}
+
 const deleteFollow = `-- name: DeleteFollow :exec
 DELETE FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2
@@ -101,8 +78,33 @@ func (q *Queries) DeleteFollow(ctx context.Context, arg DeleteFollowParams) erro
 	return err
 }
 
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id, title
+FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type GetFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
+	)
+	return i, err
+}
+
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feed_follows.user_id, feed_follows.feed_id, name, url
+SELECT feed_follows.user_id, feed_follows.feed_id, COALESCE(feed_follows.title, feeds.name)::text AS name, url
 FROM feed_follows
 INNER JOIN feeds
     ON feed_follows.feed_id = feeds.id
@@ -144,6 +146,72 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	return items, nil
 }
 
+const getFollowedFeeds = `-- name: GetFollowedFeeds :many
+SELECT feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.created_at, feeds.updated_at, feeds.last_fetched_at, feeds.api_id, feeds.retention_max_age_seconds, feeds.retention_max_posts, feeds.next_fetch_at, feeds.refresh_interval_seconds, feeds.fetch_interval_seconds, feeds.fetch_interval_source, COALESCE(feed_follows.title, feeds.name)::text AS title
+FROM feeds
+INNER JOIN feed_follows
+    ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+ORDER BY title
+`
+
+type GetFollowedFeedsRow struct {
+	ID                     uuid.UUID
+	Name                   string
+	Url                    string
+	UserID                 uuid.NullUUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	LastFetchedAt          sql.NullTime
+	ApiID                  int64
+	RetentionMaxAgeSeconds sql.NullInt64
+	RetentionMaxPosts      sql.NullInt32
+	NextFetchAt            sql.NullTime
+	RefreshIntervalSeconds sql.NullInt64
+	FetchIntervalSeconds   sql.NullInt64
+	FetchIntervalSource    sql.NullString
+	Title                  string
+}
+
+func (q *Queries) GetFollowedFeeds(ctx context.Context, userID uuid.UUID) ([]GetFollowedFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowedFeedsRow
+	for rows.Next() {
+		var i GetFollowedFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastFetchedAt,
+			&i.ApiID,
+			&i.RetentionMaxAgeSeconds,
+			&i.RetentionMaxPosts,
+			&i.NextFetchAt,
+			&i.RefreshIntervalSeconds,
+			&i.FetchIntervalSeconds,
+			&i.FetchIntervalSource,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const resetFeedFollows = `-- name: ResetFeedFollows :exec
 DELETE FROM feed_follows
 `
@@ -152,3 +220,29 @@ func (q *Queries) ResetFeedFollows(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetFeedFollows)
 	return err
 }
+
+const setFeedFollowTitle = `-- name: SetFeedFollowTitle :execrows
+UPDATE feed_follows
+SET title = $3, updated_at = $4
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type SetFeedFollowTitleParams struct {
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+	Title     sql.NullString
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedFollowTitle(ctx context.Context, arg SetFeedFollowTitleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowTitle,
+		arg.UserID,
+		arg.FeedID,
+		arg.Title,
+		arg.UpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
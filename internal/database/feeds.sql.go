@@ -13,6 +13,22 @@ import (
 	"github.com/google/uuid"
 )
 
+const countOverdueFeeds = `-- name: CountOverdueFeeds :one
+SELECT COUNT(*)
+FROM feeds
+WHERE COALESCE(next_fetch_at, last_fetched_at) IS NULL
+    OR COALESCE(next_fetch_at, last_fetched_at) < $1::timestamp
+`
+
+// Counts the feeds due since before the given time, including the ones
+// never fetched.
+func (q *Queries) CountOverdueFeeds(ctx context.Context, before time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOverdueFeeds, before)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, name, url, user_id, created_at, updated_at)
 VALUES (
@@ -23,14 +39,14 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, name, url, user_id, created_at, updated_at, last_fetched_at
+RETURNING id, name, url, user_id, created_at, updated_at, last_fetched_at, api_id, retention_max_age_seconds, retention_max_posts, next_fetch_at, refresh_interval_seconds, fetch_interval_seconds, fetch_interval_source
 `
 
 type CreateFeedParams struct {
 	ID        uuid.UUID
 	Name      string
 	Url       string
-	UserID    uuid.UUID
+	UserID    uuid.NullUUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -53,12 +69,101 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.LastFetchedAt,
+		&i.ApiID,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.NextFetchAt,
+		&i.RefreshIntervalSeconds,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalSource,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const getAllFeeds = `-- name: GetAllFeeds :many
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, api_id, retention_max_age_seconds, retention_max_posts, next_fetch_at, refresh_interval_seconds, fetch_interval_seconds, fetch_interval_source
+FROM feeds
+ORDER BY name
+`
+
+func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getAllFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastFetchedAt,
+			&i.ApiID,
+			&i.RetentionMaxAgeSeconds,
+			&i.RetentionMaxPosts,
+			&i.NextFetchAt,
+			&i.RefreshIntervalSeconds,
+			&i.FetchIntervalSeconds,
+			&i.FetchIntervalSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedByAPIID = `-- name: GetFeedByAPIID :one
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, api_id, retention_max_age_seconds, retention_max_posts, next_fetch_at, refresh_interval_seconds, fetch_interval_seconds, fetch_interval_source
+FROM feeds
+WHERE api_id = $1
+`
+
+func (q *Queries) GetFeedByAPIID(ctx context.Context, apiID int64) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByAPIID, apiID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastFetchedAt,
+		&i.ApiID,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.NextFetchAt,
+		&i.RefreshIntervalSeconds,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalSource,
 	)
 	return i, err
 }
 
 const getFeedFromURL = `-- name: GetFeedFromURL :one
-SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, api_id, retention_max_age_seconds, retention_max_posts, next_fetch_at, refresh_interval_seconds, fetch_interval_seconds, fetch_interval_source
 FROM feeds
 WHERE url = $1
 `
@@ -74,18 +179,46 @@ func (q *Queries) GetFeedFromURL(ctx context.Context, url string) (Feed, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.LastFetchedAt,
+		&i.ApiID,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.NextFetchAt,
+		&i.RefreshIntervalSeconds,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalSource,
 	)
 	return i, err
 }
 
+const getFeedUsage = `-- name: GetFeedUsage :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = $1) AS followers,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = $1) AS posts
+`
+
+type GetFeedUsageRow struct {
+	Followers int64
+	Posts     int64
+}
+
+func (q *Queries) GetFeedUsage(ctx context.Context, feedID uuid.UUID) (GetFeedUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedUsage, feedID)
+	var i GetFeedUsageRow
+	err := row.Scan(&i.Followers, &i.Posts)
+	return i, err
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, api_id, retention_max_age_seconds, retention_max_posts, next_fetch_at, refresh_interval_seconds, fetch_interval_seconds, fetch_interval_source
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+WHERE next_fetch_at IS NULL OR next_fetch_at <= $1::timestamp
+ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+LIMIT 1
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+// Returns the feed due the longest, feeds never fetched first.
+func (q *Queries) GetNextFeedToFetch(ctx context.Context, now time.Time) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getNextFeedToFetch, now)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -95,23 +228,42 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.LastFetchedAt,
+		&i.ApiID,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.NextFetchAt,
+		&i.RefreshIntervalSeconds,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalSource,
 	)
 	return i, err
 }
 
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 UPDATE feeds
-SET last_fetched_at = $2, updated_at = $2
+SET last_fetched_at = $2, next_fetch_at = $3, updated_at = $2
 WHERE id = $1
 `
 
 type MarkFeedFetchedParams struct {
 	ID            uuid.UUID
 	LastFetchedAt sql.NullTime
+	NextFetchAt   sql.NullTime
 }
 
+// Also schedules the next attempt, in case this one fails.
 func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.ID, arg.LastFetchedAt)
+	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.ID, arg.LastFetchedAt, arg.NextFetchAt)
+	return err
+}
+
+const resetFeedFetchState = `-- name: ResetFeedFetchState :exec
+UPDATE feeds
+SET last_fetched_at = NULL, next_fetch_at = NULL
+`
+
+func (q *Queries) ResetFeedFetchState(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetFeedFetchState)
 	return err
 }
 
@@ -123,3 +275,94 @@ func (q *Queries) ResetFeeds(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetFeeds)
 	return err
 }
+
+const scheduleFeedFetch = `-- name: ScheduleFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = $1::timestamp,
+    fetch_interval_seconds = $2::bigint,
+    fetch_interval_source = $3::text
+WHERE id = $4
+`
+
+type ScheduleFeedFetchParams struct {
+	NextFetchAt          time.Time
+	FetchIntervalSeconds int64
+	FetchIntervalSource  string
+	ID                   uuid.UUID
+}
+
+func (q *Queries) ScheduleFeedFetch(ctx context.Context, arg ScheduleFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleFeedFetch,
+		arg.NextFetchAt,
+		arg.FetchIntervalSeconds,
+		arg.FetchIntervalSource,
+		arg.ID,
+	)
+	return err
+}
+
+const setFeedOwner = `-- name: SetFeedOwner :exec
+UPDATE feeds
+SET user_id = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetFeedOwnerParams struct {
+	ID        uuid.UUID
+	UserID    uuid.NullUUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedOwner(ctx context.Context, arg SetFeedOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedOwner, arg.ID, arg.UserID, arg.UpdatedAt)
+	return err
+}
+
+const updateFeed = `-- name: UpdateFeed :one
+UPDATE feeds
+SET name = $2, url = $3, retention_max_age_seconds = $4, retention_max_posts = $5, refresh_interval_seconds = $6, next_fetch_at = $7, updated_at = $8
+WHERE id = $1
+RETURNING id, name, url, user_id, created_at, updated_at, last_fetched_at, api_id, retention_max_age_seconds, retention_max_posts, next_fetch_at, refresh_interval_seconds, fetch_interval_seconds, fetch_interval_source
+`
+
+type UpdateFeedParams struct {
+	ID                     uuid.UUID
+	Name                   string
+	Url                    string
+	RetentionMaxAgeSeconds sql.NullInt64
+	RetentionMaxPosts      sql.NullInt32
+	RefreshIntervalSeconds sql.NullInt64
+	NextFetchAt            sql.NullTime
+	UpdatedAt              time.Time
+}
+
+func (q *Queries) UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeed,
+		arg.ID,
+		arg.Name,
+		arg.Url,
+		arg.RetentionMaxAgeSeconds,
+		arg.RetentionMaxPosts,
+		arg.RefreshIntervalSeconds,
+		arg.NextFetchAt,
+		arg.UpdatedAt,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastFetchedAt,
+		&i.ApiID,
+		&i.RetentionMaxAgeSeconds,
+		&i.RetentionMaxPosts,
+		&i.NextFetchAt,
+		&i.RefreshIntervalSeconds,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalSource,
+	)
+	return i, err
+}
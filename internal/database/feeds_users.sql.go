@@ -9,10 +9,40 @@ import (
 	"context"
 )
 
+const getDataCounts = `-- name: GetDataCounts :one
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM feeds) AS feeds,
+    (SELECT COUNT(*) FROM feed_follows) AS feed_follows,
+    (SELECT COUNT(*) FROM posts) AS posts,
+    (SELECT COUNT(*) FROM feeds WHERE last_fetched_at IS NOT NULL) AS fetched_feeds
+`
+
+type GetDataCountsRow struct {
+	Users        int64
+	Feeds        int64
+	FeedFollows  int64
+	Posts        int64
+	FetchedFeeds int64
+}
+
+func (q *Queries) GetDataCounts(ctx context.Context) (GetDataCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getDataCounts)
+	var i GetDataCountsRow
+	err := row.Scan(
+		&i.Users,
+		&i.Feeds,
+		&i.FeedFollows,
+		&i.Posts,
+		&i.FetchedFeeds,
+	)
+	return i, err
+}
+
 const getFeeds = `-- name: GetFeeds :many
-SELECT feeds.name, feeds.url, users.name
+SELECT feeds.name, feeds.url, COALESCE(users.name, '')::text AS name
 FROM feeds
-INNER JOIN users ON feeds.user_id = users.id
+LEFT JOIN users ON feeds.user_id = users.id
 `
 
 type GetFeedsRow struct {
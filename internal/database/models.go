@@ -11,14 +11,51 @@ import (
 	"github.com/google/uuid"
 )
 
+type Alert struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Pattern   string
+	Notifier  string
+	Target    string
+}
+
+type AlertEvent struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	AlertID   uuid.UUID
+	PostID    uuid.UUID
+	Error     sql.NullString
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	LastFetchedAt sql.NullTime
+	ID                     uuid.UUID
+	Name                   string
+	Url                    string
+	UserID                 uuid.NullUUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	LastFetchedAt          sql.NullTime
+	ApiID                  int64
+	RetentionMaxAgeSeconds sql.NullInt64
+	RetentionMaxPosts      sql.NullInt32
+	NextFetchAt            sql.NullTime
+	RefreshIntervalSeconds sql.NullInt64
+	FetchIntervalSeconds   sql.NullInt64
+	FetchIntervalSource    sql.NullString
+}
+
+type FeedFetch struct {
+	ID         uuid.UUID
+	FeedID     uuid.UUID
+	StartedAt  time.Time
+	FinishedAt time.Time
+	StatusCode sql.NullInt32
+	Bytes      int64
+	Items      int32
+	NewPosts   int32
+	Error      sql.NullString
 }
 
 type FeedFollow struct {
@@ -27,6 +64,13 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Title     sql.NullString
+}
+
+type FeedFollowTag struct {
+	FeedFollowID uuid.UUID
+	Tag          string
+	CreatedAt    time.Time
 }
 
 type Post struct {
@@ -38,11 +82,80 @@ type Post struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	ItemID      int64
+	Author      string
+	Categories  string
+}
+
+type PostState struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Read      bool
+	Starred   bool
+	UpdatedAt time.Time
+	Muted     bool
+}
+
+type PostTag struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type PrunedPost struct {
+	Url      string
+	FeedID   uuid.UUID
+	PrunedAt time.Time
+}
+
+type Rule struct {
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	UserID             uuid.UUID
+	FeedID             uuid.NullUUID
+	TitlePattern       sql.NullString
+	DescriptionPattern sql.NullString
+	Author             sql.NullString
+	Category           sql.NullString
+	Action             string
+	Tag                sql.NullString
 }
 
 type User struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Name             string
+	ApiPasswordHash  sql.NullString
+	FeverApiKey      sql.NullString
+	Email            sql.NullString
+	LastDigestAt     sql.NullTime
+	IsAdmin          bool
+	LastDigestItemID sql.NullInt64
+}
+
+type Webhook struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Name      string
+	UserID    uuid.UUID
+	Url       string
+	FeedID    uuid.NullUUID
+	Secret    string
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	WebhookID      uuid.UUID
+	PostID         uuid.UUID
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastStatusCode sql.NullInt32
+	LastError      sql.NullString
+	DeliveredAt    sql.NullTime
 }
@@ -13,18 +13,34 @@ import (
 	"github.com/google/uuid"
 )
 
-const createPost = `-- name: CreatePost :exec
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES(
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
+const countPostsForUser = `-- name: CountPostsForUser :one
+SELECT COUNT(*)
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND COALESCE(post_states.muted, false) = false
+`
+
+func (q *Queries) CountPostsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPostsForUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createPost = `-- name: CreatePost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories)
+SELECT $1::uuid, $2::timestamp, $3::timestamp, $4::text, $5::text,
+    $6::text, $7::timestamp, $8::uuid, $9::text, $10::text
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM pruned_posts
+    WHERE pruned_posts.url = $5
 )
+ON CONFLICT (url) DO NOTHING
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, item_id, author, categories
 `
 
 type CreatePostParams struct {
@@ -36,10 +52,12 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Author      string
+	Categories  string
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
-	_, err := q.db.ExecContext(ctx, createPost,
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, createPost,
 		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
@@ -48,34 +66,316 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Author,
+		arg.Categories,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.ItemID,
+		&i.Author,
+		&i.Categories,
 	)
+	return i, err
+}
+
+const deletePrunedPost = `-- name: DeletePrunedPost :exec
+DELETE FROM pruned_posts
+WHERE url = $1
+`
+
+// The feed no longer lists the url, so it can't be stored again.
+func (q *Queries) DeletePrunedPost(ctx context.Context, url string) error {
+	_, err := q.db.ExecContext(ctx, deletePrunedPost, url)
 	return err
 }
 
+const getAllPostsForUser = `-- name: GetAllPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.item_id, posts.author, posts.categories
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.item_id
+`
+
+func (q *Queries) GetAllPostsForUser(ctx context.Context, userID uuid.UUID) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPostsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.ItemID,
+			&i.Author,
+			&i.Categories,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestItemID = `-- name: GetLatestItemID :one
+SELECT COALESCE(MAX(item_id), 0)::bigint AS item_id
+FROM posts
+`
+
+func (q *Queries) GetLatestItemID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestItemID)
+	var item_id int64
+	err := row.Scan(&item_id)
+	return item_id, err
+}
+
+const getPostDataCounts = `-- name: GetPostDataCounts :one
+SELECT
+    (SELECT COUNT(*) FROM posts) AS posts,
+    (SELECT COUNT(*) FROM post_states) AS post_states,
+    (SELECT COUNT(*) FROM post_tags) AS post_tags,
+    (SELECT COUNT(*) FROM webhook_deliveries) AS webhook_deliveries,
+    (SELECT COUNT(*) FROM alert_events) AS alert_events
+`
+
+type GetPostDataCountsRow struct {
+	Posts             int64
+	PostStates        int64
+	PostTags          int64
+	WebhookDeliveries int64
+	AlertEvents       int64
+}
+
+func (q *Queries) GetPostDataCounts(ctx context.Context) (GetPostDataCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostDataCounts)
+	var i GetPostDataCountsRow
+	err := row.Scan(
+		&i.Posts,
+		&i.PostStates,
+		&i.PostTags,
+		&i.WebhookDeliveries,
+		&i.AlertEvents,
+	)
+	return i, err
+}
+
+const getPostForUserByItemID = `-- name: GetPostForUserByItemID :one
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.item_id, posts.author, posts.categories, COALESCE(feed_follows.title, feeds.name)::text AS feed_name, feeds.url AS feed_url, feeds.api_id AS feed_api_id,
+    COALESCE(post_states.read, false)::boolean AS read,
+    COALESCE(post_states.starred, false)::boolean AS starred,
+    COALESCE(post_states.muted, false)::boolean AS muted
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.item_id = $2
+`
+
+type GetPostForUserByItemIDParams struct {
+	UserID uuid.UUID
+	ItemID int64
+}
+
+type GetPostForUserByItemIDRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	ItemID      int64
+	Author      string
+	Categories  string
+	FeedName    string
+	FeedUrl     string
+	FeedApiID   int64
+	Read        bool
+	Starred     bool
+	Muted       bool
+}
+
+func (q *Queries) GetPostForUserByItemID(ctx context.Context, arg GetPostForUserByItemIDParams) (GetPostForUserByItemIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostForUserByItemID, arg.UserID, arg.ItemID)
+	var i GetPostForUserByItemIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.ItemID,
+		&i.Author,
+		&i.Categories,
+		&i.FeedName,
+		&i.FeedUrl,
+		&i.FeedApiID,
+		&i.Read,
+		&i.Starred,
+		&i.Muted,
+	)
+	return i, err
+}
+
+const getPostItemIDsForUser = `-- name: GetPostItemIDsForUser :many
+SELECT posts.item_id
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+    AND COALESCE(post_states.muted, false) = false
+    AND (NOT $2::boolean OR COALESCE(post_states.read, false) = false)
+    AND (NOT $3::boolean OR COALESCE(post_states.starred, false) = true)
+ORDER BY posts.item_id
+`
+
+type GetPostItemIDsForUserParams struct {
+	UserID      uuid.UUID
+	UnreadOnly  bool
+	StarredOnly bool
+}
+
+func (q *Queries) GetPostItemIDsForUser(ctx context.Context, arg GetPostItemIDsForUserParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getPostItemIDsForUser, arg.UserID, arg.UnreadOnly, arg.StarredOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var item_id int64
+		if err := rows.Scan(&item_id); err != nil {
+			return nil, err
+		}
+		items = append(items, item_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsAfterItemID = `-- name: GetPostsAfterItemID :many
+SELECT item_id, feed_id
+FROM posts
+WHERE item_id > $1
+ORDER BY item_id
+`
+
+type GetPostsAfterItemIDRow struct {
+	ItemID int64
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetPostsAfterItemID(ctx context.Context, itemID int64) ([]GetPostsAfterItemIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsAfterItemID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsAfterItemIDRow
+	for rows.Next() {
+		var i GetPostsAfterItemIDRow
+		if err := rows.Scan(&i.ItemID, &i.FeedID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.item_id, posts.author, posts.categories, COALESCE(feed_follows.title, feeds.name)::text AS feed_name
 FROM posts
 INNER JOIN feed_follows
     ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2
+    AND COALESCE(post_states.muted, false) = false
+    AND ($2::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM feed_follow_tags
+        WHERE feed_follow_tags.feed_follow_id = feed_follows.id AND feed_follow_tags.tag = $2
+    ))
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT $3
 `
 
 type GetPostsForUserParams struct {
 	UserID uuid.UUID
+	Tag    sql.NullString
 	Limit  int32
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	ItemID      int64
+	Author      string
+	Categories  string
+	FeedName    string
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Tag, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i GetPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -85,6 +385,10 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.ItemID,
+			&i.Author,
+			&i.Categories,
+			&i.FeedName,
 		); err != nil {
 			return nil, err
 		}
@@ -99,6 +403,336 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	return items, nil
 }
 
+const getPrunedPostURLsForFeed = `-- name: GetPrunedPostURLsForFeed :many
+SELECT url
+FROM pruned_posts
+WHERE feed_id = $1
+`
+
+func (q *Queries) GetPrunedPostURLsForFeed(ctx context.Context, feedID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getPrunedPostURLsForFeed, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		items = append(items, url)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentPublishTimes = `-- name: GetRecentPublishTimes :many
+SELECT published_at::timestamp AS published_at
+FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT $2
+`
+
+type GetRecentPublishTimesParams struct {
+	FeedID uuid.UUID
+	Limit  int32
+}
+
+// Returns when the feed's latest posts were published, newest first.
+func (q *Queries) GetRecentPublishTimes(ctx context.Context, arg GetRecentPublishTimesParams) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPublishTimes, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var published_at time.Time
+		if err := rows.Scan(&published_at); err != nil {
+			return nil, err
+		}
+		items = append(items, published_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStreamForUser = `-- name: GetStreamForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.item_id, posts.author, posts.categories, COALESCE(feed_follows.title, feeds.name)::text AS feed_name, feeds.url AS feed_url, feeds.api_id AS feed_api_id,
+    COALESCE(post_states.read, false)::boolean AS read,
+    COALESCE(post_states.starred, false)::boolean AS starred,
+    COALESCE(post_states.muted, false)::boolean AS muted
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+    AND COALESCE(post_states.muted, false) = false
+    AND ($2::uuid IS NULL OR posts.feed_id = $2)
+    AND ($3::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM feed_follow_tags
+        WHERE feed_follow_tags.feed_follow_id = feed_follows.id AND feed_follow_tags.tag = $3
+    ))
+    AND (NOT $4::boolean OR COALESCE(post_states.read, false) = false)
+    AND (NOT $5::boolean OR COALESCE(post_states.read, false) = true)
+    AND (NOT $6::boolean OR COALESCE(post_states.starred, false) = true)
+    AND ($7::timestamp IS NULL OR posts.created_at >= $7)
+    AND ($8::timestamp IS NULL OR posts.created_at <= $8)
+    AND ($9::bigint IS NULL OR posts.item_id > $9)
+    AND ($10::bigint IS NULL OR posts.item_id < $10)
+ORDER BY
+    CASE WHEN $11::boolean THEN posts.item_id END ASC,
+    posts.item_id DESC
+LIMIT $13 OFFSET $12
+`
+
+type GetStreamForUserParams struct {
+	UserID       uuid.UUID
+	FeedID       uuid.NullUUID
+	Tag          sql.NullString
+	UnreadOnly   bool
+	ReadOnly     bool
+	StarredOnly  bool
+	NewerThan    sql.NullTime
+	OlderThan    sql.NullTime
+	AfterItemID  sql.NullInt64
+	BeforeItemID sql.NullInt64
+	OldestFirst  bool
+	SkipItems    int32
+	MaxItems     int32
+}
+
+type GetStreamForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	ItemID      int64
+	Author      string
+	Categories  string
+	FeedName    string
+	FeedUrl     string
+	FeedApiID   int64
+	Read        bool
+	Starred     bool
+	Muted       bool
+}
+
+func (q *Queries) GetStreamForUser(ctx context.Context, arg GetStreamForUserParams) ([]GetStreamForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getStreamForUser,
+		arg.UserID,
+		arg.FeedID,
+		arg.Tag,
+		arg.UnreadOnly,
+		arg.ReadOnly,
+		arg.StarredOnly,
+		arg.NewerThan,
+		arg.OlderThan,
+		arg.AfterItemID,
+		arg.BeforeItemID,
+		arg.OldestFirst,
+		arg.SkipItems,
+		arg.MaxItems,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStreamForUserRow
+	for rows.Next() {
+		var i GetStreamForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.ItemID,
+			&i.Author,
+			&i.Categories,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedApiID,
+			&i.Read,
+			&i.Starred,
+			&i.Muted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadCountsForUser = `-- name: GetUnreadCountsForUser :many
+SELECT posts.feed_id, feeds.url AS feed_url, COUNT(*) AS unread_count,
+    MAX(posts.created_at)::timestamp AS newest_created_at
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+    AND COALESCE(post_states.read, false) = false
+    AND COALESCE(post_states.muted, false) = false
+GROUP BY posts.feed_id, feeds.url
+`
+
+type GetUnreadCountsForUserRow struct {
+	FeedID          uuid.UUID
+	FeedUrl         string
+	UnreadCount     int64
+	NewestCreatedAt time.Time
+}
+
+func (q *Queries) GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]GetUnreadCountsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreadCountsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnreadCountsForUserRow
+	for rows.Next() {
+		var i GetUnreadCountsForUserRow
+		if err := rows.Scan(
+			&i.FeedID,
+			&i.FeedUrl,
+			&i.UnreadCount,
+			&i.NewestCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markPostsPruned = `-- name: MarkPostsPruned :exec
+INSERT INTO pruned_posts (url, feed_id, pruned_at)
+SELECT posts.url, posts.feed_id, $1::timestamp
+FROM posts
+WHERE posts.feed_id = $2
+    AND NOT EXISTS (
+        SELECT 1
+        FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred = true
+    )
+    AND (posts.created_at < $3 OR (
+        $4::integer > 0 AND posts.item_id <= COALESCE((
+            SELECT newest.item_id
+            FROM posts AS newest
+            WHERE newest.feed_id = $2
+            ORDER BY newest.item_id DESC
+            LIMIT 1 OFFSET $4
+        ), 0)
+    ))
+ON CONFLICT (url) DO NOTHING
+`
+
+type MarkPostsPrunedParams struct {
+	PrunedAt   time.Time
+	FeedID     uuid.UUID
+	OlderThan  sql.NullTime
+	KeepNewest int32
+}
+
+// Takes the same arguments as PrunePosts, and must run right before it.
+func (q *Queries) MarkPostsPruned(ctx context.Context, arg MarkPostsPrunedParams) error {
+	_, err := q.db.ExecContext(ctx, markPostsPruned,
+		arg.PrunedAt,
+		arg.FeedID,
+		arg.OlderThan,
+		arg.KeepNewest,
+	)
+	return err
+}
+
+const notifyNewPost = `-- name: NotifyNewPost :exec
+SELECT pg_notify($1, $2)
+`
+
+type NotifyNewPostParams struct {
+	Channel string
+	Payload string
+}
+
+func (q *Queries) NotifyNewPost(ctx context.Context, arg NotifyNewPostParams) error {
+	_, err := q.db.ExecContext(ctx, notifyNewPost, arg.Channel, arg.Payload)
+	return err
+}
+
+const prunePosts = `-- name: PrunePosts :execrows
+DELETE FROM posts
+WHERE posts.feed_id = $1
+    AND NOT EXISTS (
+        SELECT 1
+        FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred = true
+    )
+    AND (posts.created_at < $2 OR (
+        $3::integer > 0 AND posts.item_id <= COALESCE((
+            SELECT newest.item_id
+            FROM posts AS newest
+            WHERE newest.feed_id = $1
+            ORDER BY newest.item_id DESC
+            LIMIT 1 OFFSET $3
+        ), 0)
+    ))
+`
+
+type PrunePostsParams struct {
+	FeedID     uuid.UUID
+	OlderThan  sql.NullTime
+	KeepNewest int32
+}
+
+// Deletes the feed's posts stored before older_than, and the ones beyond
+// its keep_newest latest (0 for no limit). Starred posts are kept.
+func (q *Queries) PrunePosts(ctx context.Context, arg PrunePostsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePosts, arg.FeedID, arg.OlderThan, arg.KeepNewest)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const resetPosts = `-- name: ResetPosts :exec
 DELETE FROM posts
 `
@@ -107,3 +741,12 @@ func (q *Queries) ResetPosts(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetPosts)
 	return err
 }
+
+const resetPrunedPosts = `-- name: ResetPrunedPosts :exec
+DELETE FROM pruned_posts
+`
+
+func (q *Queries) ResetPrunedPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetPrunedPosts)
+	return err
+}
@@ -7,20 +7,47 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*)
+FROM users
+WHERE is_admin = true
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*)
+FROM users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, is_admin)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, api_password_hash, fever_api_key, email, last_digest_at, is_admin, last_digest_item_id
 `
 
 type CreateUserParams struct {
@@ -28,6 +55,7 @@ type CreateUserParams struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
+	IsAdmin   bool
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -36,6 +64,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
+		arg.IsAdmin,
 	)
 	var i User
 	err := row.Scan(
@@ -43,12 +72,69 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiPasswordHash,
+		&i.FeverApiKey,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.IsAdmin,
+		&i.LastDigestItemID,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const getDigestRecipients = `-- name: GetDigestRecipients :many
+SELECT id, created_at, updated_at, name, api_password_hash, fever_api_key, email, last_digest_at, is_admin, last_digest_item_id
+FROM users
+WHERE email IS NOT NULL
+ORDER BY name
+`
+
+func (q *Queries) GetDigestRecipients(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getDigestRecipients)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.ApiPasswordHash,
+			&i.FeverApiKey,
+			&i.Email,
+			&i.LastDigestAt,
+			&i.IsAdmin,
+			&i.LastDigestItemID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name
+SELECT id, created_at, updated_at, name, api_password_hash, fever_api_key, email, last_digest_at, is_admin, last_digest_item_id
 FROM users
 WHERE name = $1
 `
@@ -61,12 +147,78 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiPasswordHash,
+		&i.FeverApiKey,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.IsAdmin,
+		&i.LastDigestItemID,
+	)
+	return i, err
+}
+
+const getUserByFeverAPIKey = `-- name: GetUserByFeverAPIKey :one
+SELECT id, created_at, updated_at, name, api_password_hash, fever_api_key, email, last_digest_at, is_admin, last_digest_item_id
+FROM users
+WHERE fever_api_key = $1
+`
+
+func (q *Queries) GetUserByFeverAPIKey(ctx context.Context, feverApiKey sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByFeverAPIKey, feverApiKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiPasswordHash,
+		&i.FeverApiKey,
+		&i.Email,
+		&i.LastDigestAt,
+		&i.IsAdmin,
+		&i.LastDigestItemID,
+	)
+	return i, err
+}
+
+const getUserUsage = `-- name: GetUserUsage :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS follows,
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS owned_feeds,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = $1) AS post_states,
+    (SELECT COUNT(*) FROM post_tags WHERE post_tags.user_id = $1) AS post_tags,
+    (SELECT COUNT(*) FROM webhooks WHERE webhooks.user_id = $1) AS webhooks,
+    (SELECT COUNT(*) FROM rules WHERE rules.user_id = $1) AS rules,
+    (SELECT COUNT(*) FROM alerts WHERE alerts.user_id = $1) AS alerts
+`
+
+type GetUserUsageRow struct {
+	Follows    int64
+	OwnedFeeds int64
+	PostStates int64
+	PostTags   int64
+	Webhooks   int64
+	Rules      int64
+	Alerts     int64
+}
+
+func (q *Queries) GetUserUsage(ctx context.Context, userID uuid.UUID) (GetUserUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserUsage, userID)
+	var i GetUserUsageRow
+	err := row.Scan(
+		&i.Follows,
+		&i.OwnedFeeds,
+		&i.PostStates,
+		&i.PostTags,
+		&i.Webhooks,
+		&i.Rules,
+		&i.Alerts,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
-SELECT id, created_at, updated_at, name
+SELECT id, created_at, updated_at, name, api_password_hash, fever_api_key, email, last_digest_at, is_admin, last_digest_item_id
 FROM users
 `
 
@@ -84,6 +236,12 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.ApiPasswordHash,
+			&i.FeverApiKey,
+			&i.Email,
+			&i.LastDigestAt,
+			&i.IsAdmin,
+			&i.LastDigestItemID,
 		); err != nil {
 			return nil, err
 		}
@@ -98,6 +256,42 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const markDigestSent = `-- name: MarkDigestSent :exec
+UPDATE users
+SET last_digest_at = $2, last_digest_item_id = $3
+WHERE id = $1
+`
+
+type MarkDigestSentParams struct {
+	ID               uuid.UUID
+	LastDigestAt     sql.NullTime
+	LastDigestItemID sql.NullInt64
+}
+
+func (q *Queries) MarkDigestSent(ctx context.Context, arg MarkDigestSentParams) error {
+	_, err := q.db.ExecContext(ctx, markDigestSent, arg.ID, arg.LastDigestAt, arg.LastDigestItemID)
+	return err
+}
+
+const renameUser = `-- name: RenameUser :exec
+UPDATE users
+SET name = $2, api_password_hash = NULL, fever_api_key = NULL, updated_at = $3
+WHERE id = $1
+`
+
+type RenameUserParams struct {
+	ID        uuid.UUID
+	Name      string
+	UpdatedAt time.Time
+}
+
+// API credentials are derived from the user name, so renaming a user
+// clears them until a new password is set.
+func (q *Queries) RenameUser(ctx context.Context, arg RenameUserParams) error {
+	_, err := q.db.ExecContext(ctx, renameUser, arg.ID, arg.Name, arg.UpdatedAt)
+	return err
+}
+
 const resetUsers = `-- name: ResetUsers :exec
 DELETE FROM users
 `
@@ -106,3 +300,60 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetUsers)
 	return err
 }
+
+const setUserAPIPassword = `-- name: SetUserAPIPassword :exec
+UPDATE users
+SET api_password_hash = $2, fever_api_key = $3, updated_at = $4
+WHERE id = $1
+`
+
+type SetUserAPIPasswordParams struct {
+	ID              uuid.UUID
+	ApiPasswordHash sql.NullString
+	FeverApiKey     sql.NullString
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) SetUserAPIPassword(ctx context.Context, arg SetUserAPIPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAPIPassword,
+		arg.ID,
+		arg.ApiPasswordHash,
+		arg.FeverApiKey,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const setUserAdmin = `-- name: SetUserAdmin :exec
+UPDATE users
+SET is_admin = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetUserAdminParams struct {
+	ID        uuid.UUID
+	IsAdmin   bool
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAdmin, arg.ID, arg.IsAdmin, arg.UpdatedAt)
+	return err
+}
+
+const setUserEmail = `-- name: SetUserEmail :exec
+UPDATE users
+SET email = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetUserEmailParams struct {
+	ID        uuid.UUID
+	Email     sql.NullString
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetUserEmail(ctx context.Context, arg SetUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, setUserEmail, arg.ID, arg.Email, arg.UpdatedAt)
+	return err
+}
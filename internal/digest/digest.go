@@ -0,0 +1,157 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+)
+
+const summaryLength = 280
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	textTemplate = texttemplate.Must(texttemplate.New("digest.txt.tmpl").
+			Funcs(texttemplate.FuncMap{"summary": summary}).
+			ParseFS(templateFS, "templates/digest.txt.tmpl"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html.tmpl").
+			Funcs(htmltemplate.FuncMap{"summary": summary}).
+			ParseFS(templateFS, "templates/digest.html.tmpl"))
+
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+type Post struct {
+	Title       string
+	URL         string
+	FeedName    string
+	Description string
+	PublishedAt time.Time
+}
+
+// Digest is the data the email templates are rendered with.
+type Digest struct {
+	UserName string
+	Since    time.Time
+	Posts    []Post
+}
+
+// Text renders the plain-text version of the digest.
+func Text(d Digest) (string, error) {
+	var buf bytes.Buffer
+	err := textTemplate.Execute(&buf, d)
+	if err != nil {
+		return "", fmt.Errorf("Error rendering the text digest: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// Message renders the digest as a multipart/alternative email, with a
+// plain-text and an HTML part, ready to be handed to Send.
+func Message(d Digest, from, to string) ([]byte, error) {
+	var msg bytes.Buffer
+	parts := multipart.NewWriter(&msg)
+
+	subject := fmt.Sprintf("Gator digest: %v new posts", len(d.Posts))
+	if len(d.Posts) == 1 {
+		subject = "Gator digest: 1 new post"
+	}
+	fmt.Fprintf(&msg, "From: %v\r\n", from)
+	fmt.Fprintf(&msg, "To: %v\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+
+	err := writePart(parts, "text/plain; charset=utf-8", func(w io.Writer) error {
+		return textTemplate.Execute(w, d)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error rendering the text digest: %v", err)
+	}
+	err = writePart(parts, "text/html; charset=utf-8", func(w io.Writer) error {
+		return htmlTemplate.Execute(w, d)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error rendering the HTML digest: %v", err)
+	}
+
+	err = parts.Close()
+	if err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+func writePart(parts *multipart.Writer, contentType string, render func(io.Writer) error) error {
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	encoder := quotedprintable.NewWriter(part)
+	err = render(encoder)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Send delivers a message rendered by Message through the configured SMTP
+// server, authenticating only when a username is configured.
+func Send(smtpConfig config.SMTPConfig, to string, message []byte) error {
+	if smtpConfig.Host == "" {
+		return fmt.Errorf("Error: no SMTP server configured")
+	}
+	from, err := mail.ParseAddress(smtpConfig.From)
+	if err != nil {
+		return fmt.Errorf("Error parsing the sender address '%v': %v", smtpConfig.From, err)
+	}
+	port := smtpConfig.Port
+	if port == 0 {
+		port = 25
+	}
+
+	var smtpAuth smtp.Auth
+	if smtpConfig.Username != "" {
+		smtpAuth = smtp.PlainAuth("", smtpConfig.Username, smtpConfig.Password, smtpConfig.Host)
+	}
+	address := net.JoinHostPort(smtpConfig.Host, strconv.Itoa(port))
+	err = smtp.SendMail(address, smtpAuth, from.Address, []string{to}, message)
+	if err != nil {
+		return fmt.Errorf("Error sending the email to %v: %v", to, err)
+	}
+	return nil
+}
+
+// summary turns a post description, usually HTML, into a short line of text.
+func summary(description string) string {
+	text := html.UnescapeString(tagPattern.ReplaceAllString(description, " "))
+	text = strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+	runes := []rune(text)
+	if len(runes) > summaryLength {
+		return strings.TrimSpace(string(runes[:summaryLength])) + "..."
+	}
+	return text
+}
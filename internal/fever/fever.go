@@ -0,0 +1,430 @@
+package fever
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	apiVersion = 3
+	// Clients mark everything read through the "Kindling" super group,
+	// which has id 0, or through the "All" group.
+	kindlingGroupID = 0
+	allGroupID      = 1
+	maxItemCount    = 50
+)
+
+type group struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type item struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// Server implements the Fever API (https://feedafever.com/api) on top of
+// the posts and feed_follows tables. Every followed feed belongs to the
+// "All" group, and each of the user's feed tags is a group of its own.
+type Server struct {
+	db     database.Querier
+	logger *slog.Logger
+}
+
+func NewServer(db database.Querier, logger *slog.Logger) *Server {
+	return &Server{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response := map[string]any{
+		"api_version": apiVersion,
+		"auth":        0,
+	}
+
+	userData, err := s.db.GetUserByFeverAPIKey(r.Context(), sql.NullString{
+		String: strings.ToLower(r.FormValue("api_key")),
+		Valid:  true,
+	})
+	if err != nil {
+		s.writeJSON(w, response)
+		return
+	}
+	response["auth"] = 1
+
+	if r.FormValue("mark") != "" {
+		err = s.mark(r, userData)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+	}
+
+	feeds, err := s.db.GetFollowedFeeds(r.Context(), userData.ID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	response["last_refreshed_on_time"] = lastRefreshed(feeds)
+
+	if r.Form.Has("groups") || r.Form.Has("feeds") {
+		tags, err := s.db.GetFeedFollowTagsForUser(r.Context(), userData.ID)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		groups, feedsGroupList := feverGroups(feeds, tags)
+		if r.Form.Has("groups") {
+			response["groups"] = groups
+		}
+		if r.Form.Has("feeds") {
+			response["feeds"] = feverFeeds(feeds)
+		}
+		response["feeds_groups"] = feedsGroupList
+	}
+	if r.Form.Has("favicons") {
+		response["favicons"] = []any{}
+	}
+	if r.Form.Has("links") {
+		response["links"] = []any{}
+	}
+	if r.Form.Has("items") {
+		items, err := s.items(r, userData)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		total, err := s.db.CountPostsForUser(r.Context(), userData.ID)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		response["items"] = items
+		response["total_items"] = total
+	}
+	if r.Form.Has("unread_item_ids") {
+		itemIDs, err := s.db.GetPostItemIDsForUser(r.Context(), database.GetPostItemIDsForUserParams{
+			UserID:     userData.ID,
+			UnreadOnly: true,
+		})
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		response["unread_item_ids"] = joinIDs(itemIDs)
+	}
+	if r.Form.Has("saved_item_ids") {
+		itemIDs, err := s.db.GetPostItemIDsForUser(r.Context(), database.GetPostItemIDsForUserParams{
+			UserID:      userData.ID,
+			StarredOnly: true,
+		})
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		response["saved_item_ids"] = joinIDs(itemIDs)
+	}
+
+	s.writeJSON(w, response)
+}
+
+// items pages through the user's posts: since_id returns the oldest items
+// newer than the given ID, max_id the newest items older than it, and
+// with_ids the listed items.
+func (s *Server) items(r *http.Request, userData database.User) ([]item, error) {
+	items := []item{}
+
+	if withIDs := r.FormValue("with_ids"); withIDs != "" {
+		for i, id := range strings.Split(withIDs, ",") {
+			if i == maxItemCount {
+				break
+			}
+			itemID, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid item ID '%v'", id)
+			}
+			post, err := s.db.GetPostForUserByItemID(r.Context(), database.GetPostForUserByItemIDParams{
+				UserID: userData.ID,
+				ItemID: itemID,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, newItem(database.GetStreamForUserRow(post)))
+		}
+		return items, nil
+	}
+
+	params := database.GetStreamForUserParams{
+		UserID:   userData.ID,
+		MaxItems: maxItemCount,
+	}
+	if sinceID := r.FormValue("since_id"); sinceID != "" {
+		itemID, err := strconv.ParseInt(sinceID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid since_id '%v'", sinceID)
+		}
+		params.AfterItemID = sql.NullInt64{Int64: itemID, Valid: true}
+		params.OldestFirst = true
+	}
+	if maxID := r.FormValue("max_id"); maxID != "" {
+		itemID, err := strconv.ParseInt(maxID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid max_id '%v'", maxID)
+		}
+		params.BeforeItemID = sql.NullInt64{Int64: itemID, Valid: true}
+	}
+
+	posts, err := s.db.GetStreamForUser(r.Context(), params)
+	if err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		items = append(items, newItem(post))
+	}
+	return items, nil
+}
+
+// mark handles mark=item|feed|group with as=read|unread|saved|unsaved.
+func (s *Server) mark(r *http.Request, userData database.User) error {
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid id '%v'", r.FormValue("id"))
+	}
+	markAs := r.FormValue("as")
+
+	switch r.FormValue("mark") {
+	case "item":
+		return s.markItem(r.Context(), userData, id, markAs)
+	case "feed", "group":
+		if markAs != "read" {
+			return fmt.Errorf("Unsupported mark action '%v'", markAs)
+		}
+		params := database.MarkPostsReadForUserParams{
+			UserID:    userData.ID,
+			OlderThan: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if before := r.FormValue("before"); before != "" {
+			seconds, err := strconv.ParseInt(before, 10, 64)
+			if err != nil {
+				return fmt.Errorf("Invalid before '%v'", before)
+			}
+			params.OlderThan = time.Unix(seconds, 0)
+		}
+		if r.FormValue("mark") == "feed" {
+			feedData, err := s.db.GetFeedByAPIID(r.Context(), id)
+			if err != nil {
+				return err
+			}
+			params.FeedID = uuid.NullUUID{UUID: feedData.ID, Valid: true}
+		}
+		if r.FormValue("mark") == "group" && id != kindlingGroupID && id != allGroupID {
+			tags, err := s.db.GetFeedFollowTagsForUser(r.Context(), userData.ID)
+			if err != nil {
+				return err
+			}
+			tag, ok := groupTag(tags, id)
+			if !ok {
+				return fmt.Errorf("Unknown group %v", id)
+			}
+			params.Tag = sql.NullString{String: tag, Valid: true}
+		}
+		return s.db.MarkPostsReadForUser(r.Context(), params)
+	}
+	return fmt.Errorf("Unsupported mark target '%v'", r.FormValue("mark"))
+}
+
+func (s *Server) markItem(ctx context.Context, userData database.User, itemID int64, markAs string) error {
+	post, err := s.db.GetPostForUserByItemID(ctx, database.GetPostForUserByItemIDParams{
+		UserID: userData.ID,
+		ItemID: itemID,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch markAs {
+	case "read", "unread":
+		return s.db.SetPostRead(ctx, database.SetPostReadParams{
+			UserID:    userData.ID,
+			PostID:    post.ID,
+			Read:      markAs == "read",
+			UpdatedAt: time.Now(),
+		})
+	case "saved", "unsaved":
+		return s.db.SetPostStarred(ctx, database.SetPostStarredParams{
+			UserID:    userData.ID,
+			PostID:    post.ID,
+			Starred:   markAs == "saved",
+			UpdatedAt: time.Now(),
+		})
+	}
+	return fmt.Errorf("Unsupported mark action '%v'", markAs)
+}
+
+func newItem(post database.GetStreamForUserRow) item {
+	created := post.CreatedAt
+	if post.PublishedAt.Valid {
+		created = post.PublishedAt.Time
+	}
+	return item{
+		ID:            post.ItemID,
+		FeedID:        post.FeedApiID,
+		Title:         post.Title,
+		Author:        post.Author,
+		HTML:          post.Description.String,
+		URL:           post.Url,
+		IsSaved:       boolToInt(post.Starred),
+		IsRead:        boolToInt(post.Read),
+		CreatedOnTime: created.Unix(),
+	}
+}
+
+func feverFeeds(feeds []database.GetFollowedFeedsRow) []feed {
+	result := []feed{}
+	for _, feedData := range feeds {
+		var lastUpdated int64
+		if feedData.LastFetchedAt.Valid {
+			lastUpdated = feedData.LastFetchedAt.Time.Unix()
+		}
+		result = append(result, feed{
+			ID:                feedData.ApiID,
+			Title:             feedData.Title,
+			URL:               feedData.Url,
+			SiteURL:           feedData.Url,
+			LastUpdatedOnTime: lastUpdated,
+		})
+	}
+	return result
+}
+
+// feverGroups builds the "All" group plus one group per tag. Tag groups
+// are numbered from 2 in tag order, which is what groupTag relies on.
+func feverGroups(feeds []database.GetFollowedFeedsRow, tags []database.GetFeedFollowTagsForUserRow) ([]group, []feedsGroup) {
+	apiIDs := map[uuid.UUID]int64{}
+	feedIDs := []int64{}
+	for _, feedData := range feeds {
+		apiIDs[feedData.ID] = feedData.ApiID
+		feedIDs = append(feedIDs, feedData.ApiID)
+	}
+
+	groups := []group{{ID: allGroupID, Title: "All"}}
+	feedsGroupList := []feedsGroup{{GroupID: allGroupID, FeedIDs: joinIDs(feedIDs)}}
+	for i, tag := range tagNames(tags) {
+		groupID := int64(allGroupID + 1 + i)
+		tagFeedIDs := []int64{}
+		for _, feedTag := range tags {
+			if feedTag.Tag == tag {
+				tagFeedIDs = append(tagFeedIDs, apiIDs[feedTag.FeedID])
+			}
+		}
+		groups = append(groups, group{ID: groupID, Title: tag})
+		feedsGroupList = append(feedsGroupList, feedsGroup{GroupID: groupID, FeedIDs: joinIDs(tagFeedIDs)})
+	}
+	return groups, feedsGroupList
+}
+
+func groupTag(tags []database.GetFeedFollowTagsForUserRow, groupID int64) (string, bool) {
+	names := tagNames(tags)
+	i := groupID - allGroupID - 1
+	if i < 0 || i >= int64(len(names)) {
+		return "", false
+	}
+	return names[i], true
+}
+
+// tagNames returns the distinct tags, which the query already sorts.
+func tagNames(tags []database.GetFeedFollowTagsForUserRow) []string {
+	names := []string{}
+	for _, feedTag := range tags {
+		if len(names) == 0 || names[len(names)-1] != feedTag.Tag {
+			names = append(names, feedTag.Tag)
+		}
+	}
+	return names
+}
+
+func lastRefreshed(feeds []database.GetFollowedFeedsRow) int64 {
+	var last time.Time
+	for _, feedData := range feeds {
+		if feedData.LastFetchedAt.Valid && feedData.LastFetchedAt.Time.After(last) {
+			last = feedData.LastFetchedAt.Time
+		}
+	}
+	if last.IsZero() {
+		return 0
+	}
+	return last.Unix()
+}
+
+func joinIDs(ids []int64) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		s.logger.Error("Error writing the API response", "error", err)
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	s.logger.Error("Error handling API request", "error", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
@@ -0,0 +1,212 @@
+package fever
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/auth"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/storage/memory"
+	"github.com/google/uuid"
+)
+
+// newTestServer serves the API for a user following one feed with three
+// unread posts, an hour apart, and returns the user's api_key.
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	ctx := context.Background()
+	db := memory.New()
+	userData, err := db.CreateUser(ctx, database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      "alice",
+	})
+	if err != nil {
+		t.Fatalf("creating the user: %v", err)
+	}
+	apiKey := auth.FeverAPIKey("alice", "secret")
+	err = db.SetUserAPIPassword(ctx, database.SetUserAPIPasswordParams{
+		ID:          userData.ID,
+		FeverApiKey: sql.NullString{String: apiKey, Valid: true},
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("setting the API password: %v", err)
+	}
+	feedData, err := db.CreateFeed(ctx, database.CreateFeedParams{
+		ID:        uuid.New(),
+		Name:      "News",
+		Url:       "http://example.com/feed.xml",
+		UserID:    uuid.NullUUID{UUID: userData.ID, Valid: true},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("creating the feed: %v", err)
+	}
+	_, err = db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userData.ID,
+		FeedID:    feedData.ID,
+	})
+	if err != nil {
+		t.Fatalf("following the feed: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		created := time.Now().Add(time.Duration(i-4) * time.Hour)
+		_, err = db.CreatePost(ctx, database.CreatePostParams{
+			ID:        uuid.New(),
+			CreatedAt: created,
+			UpdatedAt: created,
+			Title:     fmt.Sprintf("Post %v", i),
+			Url:       fmt.Sprintf("http://example.com/%v", i),
+			FeedID:    feedData.ID,
+		})
+		if err != nil {
+			t.Fatalf("creating a post: %v", err)
+		}
+	}
+
+	server := httptest.NewServer(NewServer(db, slog.New(slog.DiscardHandler)))
+	t.Cleanup(server.Close)
+	return server, apiKey
+}
+
+// call posts form to the API with the api_key and decodes the response
+// into v.
+func call(t *testing.T, server *httptest.Server, apiKey string, form url.Values, v any) {
+	t.Helper()
+	form.Set("api_key", apiKey)
+	resp, err := http.PostForm(server.URL+"?api", form)
+	if err != nil {
+		t.Fatalf("calling the API: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("the API answered %v to %v", resp.Status, form.Encode())
+	}
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		t.Fatalf("decoding the response: %v", err)
+	}
+}
+
+func TestItemsPaging(t *testing.T) {
+	server, apiKey := newTestServer(t)
+
+	tests := []struct {
+		form url.Values
+		want []int64
+	}{
+		{url.Values{}, []int64{3, 2, 1}},
+		// since_id pages forward from the oldest item, and max_id back
+		// from the newest.
+		{url.Values{"since_id": {"1"}}, []int64{2, 3}},
+		{url.Values{"max_id": {"3"}}, []int64{2, 1}},
+		{url.Values{"with_ids": {"3,99,1"}}, []int64{3, 1}},
+	}
+	for _, test := range tests {
+		test.form.Set("items", "")
+		var response struct {
+			Items      []item `json:"items"`
+			TotalItems int64  `json:"total_items"`
+		}
+		call(t, server, apiKey, test.form, &response)
+		ids := []int64{}
+		for _, responseItem := range response.Items {
+			ids = append(ids, responseItem.ID)
+		}
+		if !reflect.DeepEqual(ids, test.want) || response.TotalItems != 3 {
+			t.Errorf("%v listed %v of %v items, want %v of 3", test.form.Encode(), ids, response.TotalItems, test.want)
+		}
+	}
+}
+
+func TestMarkItems(t *testing.T) {
+	server, apiKey := newTestServer(t)
+	var response map[string]any
+	call(t, server, apiKey, url.Values{"mark": {"item"}, "as": {"read"}, "id": {"2"}}, &response)
+	call(t, server, apiKey, url.Values{"mark": {"item"}, "as": {"saved"}, "id": {"1"}}, &response)
+	call(t, server, apiKey, url.Values{"mark": {"item"}, "as": {"saved"}, "id": {"3"}}, &response)
+
+	tests := []struct {
+		form url.Values
+		want map[string]string
+	}{
+		{
+			form: url.Values{},
+			want: map[string]string{"unread_item_ids": "1,3", "saved_item_ids": "1,3"},
+		},
+		{
+			form: url.Values{"mark": {"item"}, "as": {"unsaved"}, "id": {"1"}},
+			want: map[string]string{"unread_item_ids": "1,3", "saved_item_ids": "3"},
+		},
+		{
+			form: url.Values{"mark": {"item"}, "as": {"unread"}, "id": {"2"}},
+			want: map[string]string{"unread_item_ids": "1,2,3", "saved_item_ids": "3"},
+		},
+	}
+	for _, test := range tests {
+		form := test.form
+		form.Set("unread_item_ids", "")
+		form.Set("saved_item_ids", "")
+		var response struct {
+			UnreadItemIDs string `json:"unread_item_ids"`
+			SavedItemIDs  string `json:"saved_item_ids"`
+		}
+		call(t, server, apiKey, form, &response)
+		got := map[string]string{"unread_item_ids": response.UnreadItemIDs, "saved_item_ids": response.SavedItemIDs}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v answered %v, want %v", form.Encode(), got, test.want)
+		}
+	}
+}
+
+func TestWrongAPIKey(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	response := map[string]any{}
+	call(t, server, auth.FeverAPIKey("alice", "wrong"), url.Values{
+		"items":           {""},
+		"feeds":           {""},
+		"unread_item_ids": {""},
+	}, &response)
+	want := map[string]any{"api_version": float64(apiVersion), "auth": float64(0)}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("a wrong api_key answered %v, want %v", response, want)
+	}
+}
+
+func TestMarkAllGroupsRead(t *testing.T) {
+	for _, groupID := range []int{kindlingGroupID, allGroupID} {
+		t.Run(fmt.Sprintf("group %v", groupID), func(t *testing.T) {
+			server, apiKey := newTestServer(t)
+
+			var response map[string]any
+			call(t, server, apiKey, url.Values{
+				"mark":   {"group"},
+				"as":     {"read"},
+				"id":     {strconv.Itoa(groupID)},
+				"before": {strconv.FormatInt(time.Now().Unix(), 10)},
+			}, &response)
+			response = nil
+			call(t, server, apiKey, url.Values{"unread_item_ids": {""}}, &response)
+			if unread := response["unread_item_ids"]; unread != "" {
+				t.Errorf("unread_item_ids = %q after marking the group read", unread)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package greader
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Mr-Rafael/gator/internal/auth"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/rss"
+)
+
+const (
+	streamContentsPath = "/reader/api/0/stream/contents"
+	readingListStream  = "user/-/state/com.google/reading-list"
+	readStream         = "user/-/state/com.google/read"
+	starredStream      = "user/-/state/com.google/starred"
+	feedStreamPrefix   = "feed/"
+	labelStreamPrefix  = "user/-/label/"
+)
+
+// Server implements the subset of the Google Reader API spoken by clients
+// such as Reeder, FeedMe and NetNewsWire (including the FreshRSS flavour).
+type Server struct {
+	db     database.Querier
+	logger *slog.Logger
+	mux    *http.ServeMux
+}
+
+func NewServer(db database.Querier, logger *slog.Logger) *Server {
+	s := &Server{
+		db:     db,
+		logger: logger,
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/accounts/ClientLogin", s.handleClientLogin)
+	s.mux.HandleFunc("/reader/api/0/token", s.requireUser(s.handleToken))
+	s.mux.HandleFunc("/reader/api/0/user-info", s.requireUser(s.handleUserInfo))
+	s.mux.HandleFunc("/reader/api/0/subscription/list", s.requireUser(s.handleSubscriptionList))
+	s.mux.HandleFunc("POST /reader/api/0/subscription/edit", s.requireUser(s.handleSubscriptionEdit))
+	s.mux.HandleFunc("POST /reader/api/0/subscription/quickadd", s.requireUser(s.handleQuickAdd))
+	s.mux.HandleFunc("/reader/api/0/tag/list", s.requireUser(s.handleTagList))
+	s.mux.HandleFunc("/reader/api/0/unread-count", s.requireUser(s.handleUnreadCount))
+	s.mux.HandleFunc("/reader/api/0/stream/items/ids", s.requireUser(s.handleStreamItemIDs))
+	s.mux.HandleFunc("/reader/api/0/stream/items/contents", s.requireUser(s.handleStreamItemContents))
+	s.mux.HandleFunc("POST /reader/api/0/edit-tag", s.requireUser(s.handleEditTag))
+	s.mux.HandleFunc("POST /reader/api/0/mark-all-as-read", s.requireUser(s.handleMarkAllAsRead))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Stream IDs embed feed URLs ("feed/https://..."), which the ServeMux
+	// would clean and redirect, so stream contents are routed by hand.
+	if strings.HasPrefix(r.URL.Path, streamContentsPath) {
+		s.requireUser(s.handleStreamContents)(w, r)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleClientLogin(w http.ResponseWriter, r *http.Request) {
+	userName := r.FormValue("Email")
+	password := r.FormValue("Passwd")
+
+	userData, err := s.db.GetUser(r.Context(), userName)
+	if err != nil || !userData.ApiPasswordHash.Valid || !auth.CheckPassword(password, userData.ApiPasswordHash.String) {
+		http.Error(w, "Error=BadAuthentication", http.StatusForbidden)
+		return
+	}
+
+	token := auth.Token(userData.Name, userData.ApiPasswordHash.String)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "SID=%v\nLSID=null\nAuth=%v\n", token, token)
+}
+
+func (s *Server) requireUser(handler func(http.ResponseWriter, *http.Request, database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userData, err := s.authenticate(r)
+		if err != nil {
+			w.Header().Set("Google-Bad-Token", "true")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r, userData)
+	}
+}
+
+func (s *Server) authenticate(r *http.Request) (database.User, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+	if !ok {
+		return database.User{}, errors.New("missing GoogleLogin authorization header")
+	}
+	return auth.UserFromToken(r.Context(), s.db, token)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request, userData database.User) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, auth.Token(userData.Name, userData.ApiPasswordHash.String))
+}
+
+func (s *Server) handleUserInfo(w http.ResponseWriter, r *http.Request, userData database.User) {
+	s.writeJSON(w, map[string]string{
+		"userId":        userData.ID.String(),
+		"userName":      userData.Name,
+		"userProfileId": userData.ID.String(),
+		"userEmail":     "",
+	})
+}
+
+func (s *Server) handleTagList(w http.ResponseWriter, r *http.Request, userData database.User) {
+	feedTags, err := s.db.GetFeedFollowTagsForUser(r.Context(), userData.ID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	tags := []map[string]string{{"id": starredStream}}
+	seen := map[string]bool{}
+	for _, feedTag := range feedTags {
+		if seen[feedTag.Tag] {
+			continue
+		}
+		seen[feedTag.Tag] = true
+		tags = append(tags, map[string]string{
+			"id":   labelStreamPrefix + feedTag.Tag,
+			"type": "folder",
+		})
+	}
+	s.writeJSON(w, map[string][]map[string]string{"tags": tags})
+}
+
+func (s *Server) feedFromStream(ctx context.Context, streamID string) (database.Feed, error) {
+	feedURL, ok := strings.CutPrefix(streamID, feedStreamPrefix)
+	if !ok {
+		return database.Feed{}, fmt.Errorf("'%v' is not a feed stream", streamID)
+	}
+	return s.db.GetFeedFromURL(ctx, feedURL)
+}
+
+// stateName returns the com.google state a stream refers to ("read",
+// "starred", "reading-list"...), accepting both "user/-/" and "user/<id>/".
+func stateName(streamID string) string {
+	parts := strings.SplitN(streamID, "/", 3)
+	if len(parts) < 3 || parts[0] != "user" {
+		return ""
+	}
+	state, _ := strings.CutPrefix(parts[2], "state/com.google/")
+	return state
+}
+
+// labelName returns the feed tag a "user/-/label/<tag>" stream refers to.
+func labelName(streamID string) string {
+	parts := strings.SplitN(streamID, "/", 3)
+	if len(parts) < 3 || parts[0] != "user" {
+		return ""
+	}
+	label, _ := strings.CutPrefix(parts[2], "label/")
+	if label == parts[2] {
+		return ""
+	}
+	return label
+}
+
+func formValues(r *http.Request, key string) []string {
+	r.ParseForm()
+	return r.Form[key]
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		s.logger.Error("Error writing the API response", "error", err)
+	}
+}
+
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, rss.ErrInvalidFeedURL) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.logger.Error("Error handling API request", "error", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+func unescapeStreamID(escaped string) string {
+	streamID, err := url.PathUnescape(escaped)
+	if err != nil {
+		return escaped
+	}
+	return streamID
+}
@@ -0,0 +1,390 @@
+package greader
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/auth"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/storage/memory"
+	"github.com/google/uuid"
+)
+
+// newTestServer serves the API for a user following one feed, with a post
+// stored at each of the given times, and returns the user's auth token.
+func newTestServer(t *testing.T, postTimes ...time.Time) (*httptest.Server, string) {
+	t.Helper()
+	ctx := context.Background()
+	db := memory.New()
+	userData, err := db.CreateUser(ctx, database.CreateUserParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      "alice",
+	})
+	if err != nil {
+		t.Fatalf("creating the user: %v", err)
+	}
+	hash, err := auth.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("hashing the password: %v", err)
+	}
+	err = db.SetUserAPIPassword(ctx, database.SetUserAPIPasswordParams{
+		ID:              userData.ID,
+		ApiPasswordHash: sql.NullString{String: hash, Valid: true},
+		UpdatedAt:       time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("setting the API password: %v", err)
+	}
+	feedData, err := db.CreateFeed(ctx, database.CreateFeedParams{
+		ID:        uuid.New(),
+		Name:      "News",
+		Url:       "http://example.com/feed.xml",
+		UserID:    uuid.NullUUID{UUID: userData.ID, Valid: true},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("creating the feed: %v", err)
+	}
+	_, err = db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userData.ID,
+		FeedID:    feedData.ID,
+	})
+	if err != nil {
+		t.Fatalf("following the feed: %v", err)
+	}
+	for i, postTime := range postTimes {
+		_, err = db.CreatePost(ctx, database.CreatePostParams{
+			ID:        uuid.New(),
+			CreatedAt: postTime,
+			UpdatedAt: postTime,
+			Title:     fmt.Sprintf("Post %v", i+1),
+			Url:       fmt.Sprintf("http://example.com/%v", i+1),
+			FeedID:    feedData.ID,
+		})
+		if err != nil {
+			t.Fatalf("creating a post: %v", err)
+		}
+	}
+
+	server := httptest.NewServer(NewServer(db, slog.New(slog.DiscardHandler)))
+	t.Cleanup(server.Close)
+	return server, auth.Token(userData.Name, hash)
+}
+
+// get sends an authenticated GET request and decodes its JSON response
+// into v.
+func get(t *testing.T, server *httptest.Server, token, path string, v any) {
+	t.Helper()
+	req, err := http.NewRequest("GET", server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("creating the request: %v", err)
+	}
+	req.Header.Set("Authorization", "GoogleLogin auth="+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %v: %v", path, err)
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if resp.StatusCode != http.StatusOK || err != nil {
+		t.Fatalf("GET %v: status %v, %v", path, resp.StatusCode, err)
+	}
+}
+
+// post sends an authenticated form POST, failing unless it's answered
+// with the plain OK of the edit endpoints.
+func post(t *testing.T, server *httptest.Server, token, path string, form url.Values) {
+	t.Helper()
+	req, err := http.NewRequest("POST", server.URL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("creating the request: %v", err)
+	}
+	req.Header.Set("Authorization", "GoogleLogin auth="+token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %v: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || err != nil || string(body) != "OK" {
+		t.Fatalf("POST %v %v: status %v, %q, %v", path, form.Encode(), resp.StatusCode, body, err)
+	}
+}
+
+// streamTitles lists the titles of the items in the reading list, with
+// query added to the stream contents request.
+func streamTitles(t *testing.T, server *httptest.Server, token, query string) []string {
+	t.Helper()
+	var contents streamContents
+	get(t, server, token, streamContentsPath+"/"+url.PathEscape(readingListStream)+"?"+query, &contents)
+	titles := []string{}
+	for _, contentsItem := range contents.Items {
+		titles = append(titles, contentsItem.Title)
+	}
+	return titles
+}
+
+// streamItems returns the items of the reading list by title.
+func streamItems(t *testing.T, server *httptest.Server, token string) map[string]item {
+	t.Helper()
+	var contents streamContents
+	get(t, server, token, streamContentsPath+"/"+url.PathEscape(readingListStream), &contents)
+	items := map[string]item{}
+	for _, contentsItem := range contents.Items {
+		items[contentsItem.Title] = contentsItem
+	}
+	return items
+}
+
+func TestClientLogin(t *testing.T) {
+	server, token := newTestServer(t)
+
+	tests := []struct {
+		password   string
+		wantStatus int
+		wantBody   string
+	}{
+		{"secret", http.StatusOK, fmt.Sprintf("SID=%v\nLSID=null\nAuth=%v\n", token, token)},
+		{"wrong", http.StatusForbidden, "Error=BadAuthentication\n"},
+	}
+	for _, test := range tests {
+		form := url.Values{"Email": {"alice"}, "Passwd": {test.password}}
+		resp, err := http.PostForm(server.URL+"/accounts/ClientLogin", form)
+		if err != nil {
+			t.Fatalf("logging in: %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("reading the login response: %v", err)
+		}
+		if resp.StatusCode != test.wantStatus || string(body) != test.wantBody {
+			t.Errorf("logging in with '%v' answered %v %q, want %v %q", test.password, resp.StatusCode, body, test.wantStatus, test.wantBody)
+		}
+	}
+}
+
+func TestSubscriptionList(t *testing.T) {
+	server, token := newTestServer(t)
+
+	var list map[string][]subscription
+	get(t, server, token, "/reader/api/0/subscription/list?output=json", &list)
+	want := []subscription{{
+		ID:         "feed/http://example.com/feed.xml",
+		Title:      "News",
+		Categories: []category{},
+		URL:        "http://example.com/feed.xml",
+		HTMLURL:    "http://example.com/feed.xml",
+	}}
+	if !reflect.DeepEqual(list["subscriptions"], want) {
+		t.Errorf("listed %+v, want %+v", list["subscriptions"], want)
+	}
+}
+
+func TestSubscriptionEdit(t *testing.T) {
+	server, token := newTestServer(t)
+	const editPath = "/reader/api/0/subscription/edit"
+	const otherStream = "feed/http://example.org/other.xml"
+
+	post(t, server, token, editPath, url.Values{
+		"ac": {"subscribe"},
+		"s":  {otherStream},
+		"t":  {"Other"},
+		"a":  {"user/-/label/Tech"},
+	})
+	var list map[string][]subscription
+	get(t, server, token, "/reader/api/0/subscription/list", &list)
+	subscriptions := map[string]subscription{}
+	for _, sub := range list["subscriptions"] {
+		subscriptions[sub.ID] = sub
+	}
+	other, ok := subscriptions[otherStream]
+	if len(subscriptions) != 2 || !ok {
+		t.Fatalf("subscribing listed %+v, want the new feed next to the old one", list["subscriptions"])
+	}
+	wantCategories := []category{{ID: "user/-/label/Tech", Label: "Tech"}}
+	if other.Title != "Other" || !reflect.DeepEqual(other.Categories, wantCategories) {
+		t.Errorf("subscribed to %+v, want the title Other and the Tech label", other)
+	}
+
+	post(t, server, token, editPath, url.Values{
+		"ac": {"edit"},
+		"s":  {"feed/http://example.com/feed.xml"},
+		"a":  {"user/-/label/Daily"},
+	})
+	list = nil
+	get(t, server, token, "/reader/api/0/subscription/list", &list)
+	for _, sub := range list["subscriptions"] {
+		if sub.ID == "feed/http://example.com/feed.xml" && (len(sub.Categories) != 1 || sub.Categories[0].Label != "Daily") {
+			t.Errorf("adding a label listed %+v, want the Daily label", sub)
+		}
+	}
+
+	post(t, server, token, editPath, url.Values{
+		"ac": {"unsubscribe"},
+		"s":  {otherStream},
+	})
+	list = nil
+	get(t, server, token, "/reader/api/0/subscription/list", &list)
+	if len(list["subscriptions"]) != 1 || list["subscriptions"][0].ID == otherStream {
+		t.Errorf("unsubscribing listed %+v, want only the old feed", list["subscriptions"])
+	}
+}
+
+func TestUnreadCount(t *testing.T) {
+	now := time.Now()
+	server, token := newTestServer(t, now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-1*time.Hour))
+	post(t, server, token, "/reader/api/0/subscription/edit", url.Values{
+		"ac": {"edit"},
+		"s":  {"feed/http://example.com/feed.xml"},
+		"a":  {"user/-/label/Daily"},
+	})
+	post(t, server, token, "/reader/api/0/edit-tag", url.Values{
+		"i": {streamItems(t, server, token)["Post 1"].ID},
+		"a": {readStream},
+	})
+
+	var response struct {
+		Max          int64         `json:"max"`
+		UnreadCounts []unreadCount `json:"unreadcounts"`
+	}
+	get(t, server, token, "/reader/api/0/unread-count?output=json", &response)
+	counts := map[string]int64{}
+	for _, count := range response.UnreadCounts {
+		counts[count.ID] = count.Count
+	}
+	want := map[string]int64{
+		"feed/http://example.com/feed.xml": 2,
+		"user/-/label/Daily":               2,
+		readingListStream:                  2,
+	}
+	if response.Max != 2 || !reflect.DeepEqual(counts, want) {
+		t.Errorf("counted max %v, %v, want max 2, %v", response.Max, counts, want)
+	}
+}
+
+func TestEditTag(t *testing.T) {
+	now := time.Now()
+	server, token := newTestServer(t, now.Add(-2*time.Hour), now.Add(-1*time.Hour))
+	items := streamItems(t, server, token)
+	first, second := items["Post 1"].ID, items["Post 2"].ID
+
+	tests := []struct {
+		name string
+		form url.Values
+		want map[string][]string
+	}{
+		{
+			name: "setting read and starred",
+			form: url.Values{"i": {first, second}, "a": {readStream, starredStream}},
+			want: map[string][]string{
+				"Post 1": {readingListStream, readStream, starredStream},
+				"Post 2": {readingListStream, readStream, starredStream},
+			},
+		},
+		{
+			name: "clearing starred",
+			form: url.Values{"i": {first}, "r": {starredStream}},
+			want: map[string][]string{
+				"Post 1": {readingListStream, readStream},
+				"Post 2": {readingListStream, readStream, starredStream},
+			},
+		},
+		{
+			name: "clearing read",
+			form: url.Values{"i": {second}, "r": {readStream}},
+			want: map[string][]string{
+				"Post 1": {readingListStream, readStream},
+				"Post 2": {readingListStream, starredStream},
+			},
+		},
+		{
+			name: "keeping unread",
+			form: url.Values{"i": {first}, "a": {"user/-/state/com.google/kept-unread"}},
+			want: map[string][]string{
+				"Post 1": {readingListStream},
+				"Post 2": {readingListStream, starredStream},
+			},
+		},
+		{
+			// An unknown item is skipped rather than failing the batch.
+			name: "batching an unknown item",
+			form: url.Values{"i": {first, formatItemID(999)}, "a": {starredStream}},
+			want: map[string][]string{
+				"Post 1": {readingListStream, starredStream},
+				"Post 2": {readingListStream, starredStream},
+			},
+		},
+	}
+	for _, test := range tests {
+		post(t, server, token, "/reader/api/0/edit-tag", test.form)
+		got := map[string][]string{}
+		for title, streamItem := range streamItems(t, server, token) {
+			got[title] = streamItem.Categories
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v left the categories %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMarkAllAsRead(t *testing.T) {
+	now := time.Now()
+	server, token := newTestServer(t, now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-1*time.Hour))
+
+	// Only the items up to ts are marked, so newer ones the client hasn't
+	// shown yet stay unread.
+	post(t, server, token, "/reader/api/0/mark-all-as-read", url.Values{
+		"s":  {readingListStream},
+		"ts": {strconv.FormatInt(now.Add(-90*time.Minute).UnixMicro(), 10)},
+	})
+	unread := streamTitles(t, server, token, "xt="+url.QueryEscape(readStream))
+	if !reflect.DeepEqual(unread, []string{"Post 3"}) {
+		t.Errorf("left %v unread, want [Post 3]", unread)
+	}
+}
+
+func TestStreamContentsBetweenTimestamps(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	server, token := newTestServer(t,
+		now.Add(-3*time.Hour),
+		now.Add(-2*time.Hour),
+		now.Add(-1*time.Hour),
+	)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Post 3", "Post 2", "Post 1"}},
+		// ot leaves out the older items, which is how clients sync.
+		{fmt.Sprintf("ot=%v", now.Add(-150*time.Minute).Unix()), []string{"Post 3", "Post 2"}},
+		{fmt.Sprintf("nt=%v", now.Add(-150*time.Minute).Unix()), []string{"Post 1"}},
+		{fmt.Sprintf("ot=%v&nt=%v", now.Add(-150*time.Minute).Unix(), now.Add(-90*time.Minute).Unix()), []string{"Post 2"}},
+	}
+	for _, test := range tests {
+		titles := streamTitles(t, server, token, test.query)
+		if !reflect.DeepEqual(titles, test.want) {
+			t.Errorf("?%v listed %v, want %v", test.query, titles, test.want)
+		}
+	}
+}
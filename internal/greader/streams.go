@@ -0,0 +1,465 @@
+package greader
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	longItemIDPrefix = "tag:google.com,2005:reader/item/"
+	defaultItemCount = 20
+	maxItemCount     = 1000
+)
+
+type streamContents struct {
+	Direction    string `json:"direction"`
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Updated      int64  `json:"updated"`
+	Items        []item `json:"items"`
+	Continuation string `json:"continuation,omitempty"`
+}
+
+type item struct {
+	ID            string   `json:"id"`
+	CrawlTimeMsec string   `json:"crawlTimeMsec"`
+	TimestampUsec string   `json:"timestampUsec"`
+	Published     int64    `json:"published"`
+	Updated       int64    `json:"updated"`
+	Title         string   `json:"title"`
+	Author        string   `json:"author,omitempty"`
+	Canonical     []link   `json:"canonical"`
+	Alternate     []link   `json:"alternate"`
+	Summary       content  `json:"summary"`
+	Categories    []string `json:"categories"`
+	Origin        origin   `json:"origin"`
+}
+
+type link struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type content struct {
+	Direction string `json:"direction"`
+	Content   string `json:"content"`
+}
+
+type origin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type itemRef struct {
+	ID              string   `json:"id"`
+	DirectStreamIDs []string `json:"directStreamIds"`
+	TimestampUsec   string   `json:"timestampUsec"`
+}
+
+type unreadCount struct {
+	ID                      string `json:"id"`
+	Count                   int64  `json:"count"`
+	NewestItemTimestampUsec string `json:"newestItemTimestampUsec"`
+}
+
+func (s *Server) handleStreamContents(w http.ResponseWriter, r *http.Request, userData database.User) {
+	escapedID := strings.TrimPrefix(strings.TrimPrefix(r.URL.EscapedPath(), streamContentsPath), "/")
+	streamID := unescapeStreamID(escapedID)
+	if streamID == "" {
+		streamID = readingListStream
+	}
+
+	params, err := s.streamParams(r, userData, streamID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	posts, err := s.db.GetStreamForUser(r.Context(), params)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	response := newStreamContents(streamID, posts)
+	if len(posts) == int(params.MaxItems) {
+		response.Continuation = strconv.Itoa(int(params.SkipItems + params.MaxItems))
+	}
+	s.writeJSON(w, response)
+}
+
+func (s *Server) handleStreamItemIDs(w http.ResponseWriter, r *http.Request, userData database.User) {
+	streamID := r.FormValue("s")
+	if streamID == "" {
+		streamID = readingListStream
+	}
+
+	params, err := s.streamParams(r, userData, streamID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	posts, err := s.db.GetStreamForUser(r.Context(), params)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	refs := []itemRef{}
+	for _, post := range posts {
+		refs = append(refs, itemRef{
+			ID:              strconv.FormatInt(post.ItemID, 10),
+			DirectStreamIDs: []string{},
+			TimestampUsec:   strconv.FormatInt(post.CreatedAt.UnixMicro(), 10),
+		})
+	}
+	response := map[string]any{"itemRefs": refs}
+	if len(posts) == int(params.MaxItems) {
+		response["continuation"] = strconv.Itoa(int(params.SkipItems + params.MaxItems))
+	}
+	s.writeJSON(w, response)
+}
+
+func (s *Server) handleStreamItemContents(w http.ResponseWriter, r *http.Request, userData database.User) {
+	posts := []database.GetStreamForUserRow{}
+	for _, id := range formValues(r, "i") {
+		itemID, err := parseItemID(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		post, err := s.db.GetPostForUserByItemID(r.Context(), database.GetPostForUserByItemIDParams{
+			UserID: userData.ID,
+			ItemID: itemID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		posts = append(posts, database.GetStreamForUserRow(post))
+	}
+	s.writeJSON(w, newStreamContents(readingListStream, posts))
+}
+
+func (s *Server) handleUnreadCount(w http.ResponseWriter, r *http.Request, userData database.User) {
+	counts, err := s.db.GetUnreadCountsForUser(r.Context(), userData.ID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	feedTags, err := s.db.GetFeedFollowTagsForUser(r.Context(), userData.ID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var total int64
+	var newest time.Time
+	unreadCounts := []unreadCount{}
+	labelCounts := map[string]*unreadCount{}
+	labelNewest := map[string]time.Time{}
+	labels := []string{}
+	for _, count := range counts {
+		total += count.UnreadCount
+		if count.NewestCreatedAt.After(newest) {
+			newest = count.NewestCreatedAt
+		}
+		unreadCounts = append(unreadCounts, unreadCount{
+			ID:                      feedStreamPrefix + count.FeedUrl,
+			Count:                   count.UnreadCount,
+			NewestItemTimestampUsec: strconv.FormatInt(count.NewestCreatedAt.UnixMicro(), 10),
+		})
+
+		for _, feedTag := range feedTags {
+			if feedTag.FeedID != count.FeedID {
+				continue
+			}
+			labelCount, ok := labelCounts[feedTag.Tag]
+			if !ok {
+				labelCount = &unreadCount{ID: labelStreamPrefix + feedTag.Tag}
+				labelCounts[feedTag.Tag] = labelCount
+				labels = append(labels, feedTag.Tag)
+			}
+			labelCount.Count += count.UnreadCount
+			if count.NewestCreatedAt.After(labelNewest[feedTag.Tag]) {
+				labelNewest[feedTag.Tag] = count.NewestCreatedAt
+			}
+		}
+	}
+	for _, label := range labels {
+		labelCount := labelCounts[label]
+		labelCount.NewestItemTimestampUsec = strconv.FormatInt(labelNewest[label].UnixMicro(), 10)
+		unreadCounts = append(unreadCounts, *labelCount)
+	}
+	unreadCounts = append(unreadCounts, unreadCount{
+		ID:                      readingListStream,
+		Count:                   total,
+		NewestItemTimestampUsec: strconv.FormatInt(newest.UnixMicro(), 10),
+	})
+	s.writeJSON(w, map[string]any{
+		"max":          total,
+		"unreadcounts": unreadCounts,
+	})
+}
+
+func (s *Server) handleEditTag(w http.ResponseWriter, r *http.Request, userData database.User) {
+	addTags := formValues(r, "a")
+	removeTags := formValues(r, "r")
+
+	for _, id := range formValues(r, "i") {
+		itemID, err := parseItemID(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		post, err := s.db.GetPostForUserByItemID(r.Context(), database.GetPostForUserByItemIDParams{
+			UserID: userData.ID,
+			ItemID: itemID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+
+		for _, tag := range addTags {
+			err = s.setState(r, userData.ID, post.ID, stateName(tag), true)
+			if err != nil {
+				s.writeError(w, err)
+				return
+			}
+		}
+		for _, tag := range removeTags {
+			err = s.setState(r, userData.ID, post.ID, stateName(tag), false)
+			if err != nil {
+				s.writeError(w, err)
+				return
+			}
+		}
+	}
+	writeOK(w)
+}
+
+// setState applies a com.google state tag to a post. Unknown tags are
+// ignored, as clients send labels this server does not track.
+func (s *Server) setState(r *http.Request, userID, postID uuid.UUID, state string, value bool) error {
+	switch state {
+	case "read":
+		return s.db.SetPostRead(r.Context(), database.SetPostReadParams{
+			UserID:    userID,
+			PostID:    postID,
+			Read:      value,
+			UpdatedAt: time.Now(),
+		})
+	case "kept-unread":
+		return s.db.SetPostRead(r.Context(), database.SetPostReadParams{
+			UserID:    userID,
+			PostID:    postID,
+			Read:      !value,
+			UpdatedAt: time.Now(),
+		})
+	case "starred":
+		return s.db.SetPostStarred(r.Context(), database.SetPostStarredParams{
+			UserID:    userID,
+			PostID:    postID,
+			Starred:   value,
+			UpdatedAt: time.Now(),
+		})
+	}
+	return nil
+}
+
+func (s *Server) handleMarkAllAsRead(w http.ResponseWriter, r *http.Request, userData database.User) {
+	params := database.MarkPostsReadForUserParams{
+		UserID:    userData.ID,
+		OlderThan: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	streamID := r.FormValue("s")
+	if strings.HasPrefix(streamID, feedStreamPrefix) {
+		feedData, err := s.feedFromStream(r.Context(), streamID)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedData.ID, Valid: true}
+	}
+	if label := labelName(streamID); label != "" {
+		params.Tag = sql.NullString{String: label, Valid: true}
+	}
+	if ts := r.FormValue("ts"); ts != "" {
+		usec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid timestamp '%v'", ts), http.StatusBadRequest)
+			return
+		}
+		params.OlderThan = time.UnixMicro(usec)
+	}
+
+	err := s.db.MarkPostsReadForUser(r.Context(), params)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	writeOK(w)
+}
+
+// streamParams translates a stream ID and the usual query parameters
+// (n, c, r, xt, it, ot, nt) into a stream query.
+func (s *Server) streamParams(r *http.Request, userData database.User, streamID string) (database.GetStreamForUserParams, error) {
+	params := database.GetStreamForUserParams{
+		UserID:   userData.ID,
+		MaxItems: defaultItemCount,
+	}
+
+	switch {
+	case strings.HasPrefix(streamID, feedStreamPrefix):
+		feedData, err := s.feedFromStream(r.Context(), streamID)
+		if err != nil {
+			return params, fmt.Errorf("Unknown feed stream '%v'", streamID)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedData.ID, Valid: true}
+	case labelName(streamID) != "":
+		params.Tag = sql.NullString{String: labelName(streamID), Valid: true}
+	case stateName(streamID) == "reading-list":
+	case stateName(streamID) == "starred":
+		params.StarredOnly = true
+	case stateName(streamID) == "read":
+		params.ReadOnly = true
+	default:
+		return params, fmt.Errorf("Unsupported stream '%v'", streamID)
+	}
+
+	for _, exclude := range formValues(r, "xt") {
+		if stateName(exclude) == "read" {
+			params.UnreadOnly = true
+		}
+	}
+	for _, include := range formValues(r, "it") {
+		switch stateName(include) {
+		case "read":
+			params.ReadOnly = true
+		case "starred":
+			params.StarredOnly = true
+		}
+	}
+
+	if n := r.FormValue("n"); n != "" {
+		count, err := strconv.Atoi(n)
+		if err != nil || count <= 0 {
+			return params, fmt.Errorf("Invalid item count '%v'", n)
+		}
+		params.MaxItems = int32(min(count, maxItemCount))
+	}
+	if c := r.FormValue("c"); c != "" {
+		offset, err := strconv.Atoi(c)
+		if err != nil || offset < 0 {
+			return params, fmt.Errorf("Invalid continuation '%v'", c)
+		}
+		params.SkipItems = int32(offset)
+	}
+	params.OldestFirst = r.FormValue("r") == "o"
+
+	// ot leaves out the items older than it, and nt the ones newer.
+	if ot := r.FormValue("ot"); ot != "" {
+		seconds, err := strconv.ParseInt(ot, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("Invalid timestamp '%v'", ot)
+		}
+		params.NewerThan = sql.NullTime{Time: time.Unix(seconds, 0), Valid: true}
+	}
+	if nt := r.FormValue("nt"); nt != "" {
+		seconds, err := strconv.ParseInt(nt, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("Invalid timestamp '%v'", nt)
+		}
+		params.OlderThan = sql.NullTime{Time: time.Unix(seconds, 0), Valid: true}
+	}
+	return params, nil
+}
+
+func newStreamContents(streamID string, posts []database.GetStreamForUserRow) streamContents {
+	response := streamContents{
+		Direction: "ltr",
+		ID:        streamID,
+		Updated:   time.Now().Unix(),
+		Items:     []item{},
+	}
+	for _, post := range posts {
+		response.Items = append(response.Items, newItem(post))
+	}
+	return response
+}
+
+func newItem(post database.GetStreamForUserRow) item {
+	published := post.CreatedAt
+	if post.PublishedAt.Valid {
+		published = post.PublishedAt.Time
+	}
+
+	categories := []string{readingListStream}
+	if post.Read {
+		categories = append(categories, readStream)
+	}
+	if post.Starred {
+		categories = append(categories, starredStream)
+	}
+
+	return item{
+		ID:            formatItemID(post.ItemID),
+		CrawlTimeMsec: strconv.FormatInt(post.CreatedAt.UnixMilli(), 10),
+		TimestampUsec: strconv.FormatInt(post.CreatedAt.UnixMicro(), 10),
+		Published:     published.Unix(),
+		Updated:       post.UpdatedAt.Unix(),
+		Title:         post.Title,
+		Author:        post.Author,
+		Canonical:     []link{{Href: post.Url}},
+		Alternate:     []link{{Href: post.Url, Type: "text/html"}},
+		Summary: content{
+			Direction: "ltr",
+			Content:   post.Description.String,
+		},
+		Categories: categories,
+		Origin: origin{
+			StreamID: feedStreamPrefix + post.FeedUrl,
+			Title:    post.FeedName,
+			HTMLURL:  post.FeedUrl,
+		},
+	}
+}
+
+func formatItemID(itemID int64) string {
+	return fmt.Sprintf("%v%016x", longItemIDPrefix, uint64(itemID))
+}
+
+// parseItemID accepts both the long "tag:google.com,2005:reader/item/<hex>"
+// form and the short decimal form used by the item ID endpoints.
+func parseItemID(id string) (int64, error) {
+	if hexID, ok := strings.CutPrefix(id, longItemIDPrefix); ok {
+		itemID, err := strconv.ParseUint(hexID, 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid item ID '%v'", id)
+		}
+		return int64(itemID), nil
+	}
+	itemID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid item ID '%v'", id)
+	}
+	return itemID, nil
+}
@@ -0,0 +1,249 @@
+package greader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/rss"
+	"github.com/google/uuid"
+)
+
+type subscription struct {
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	Categories []category `json:"categories"`
+	URL        string     `json:"url"`
+	HTMLURL    string     `json:"htmlUrl"`
+	IconURL    string     `json:"iconUrl"`
+}
+
+type category struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+func (s *Server) handleSubscriptionList(w http.ResponseWriter, r *http.Request, userData database.User) {
+	feedFollows, err := s.db.GetFeedFollowsForUser(r.Context(), userData.ID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	feedTags, err := s.db.GetFeedFollowTagsForUser(r.Context(), userData.ID)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	categories := map[uuid.UUID][]category{}
+	for _, feedTag := range feedTags {
+		categories[feedTag.FeedID] = append(categories[feedTag.FeedID], category{
+			ID:    labelStreamPrefix + feedTag.Tag,
+			Label: feedTag.Tag,
+		})
+	}
+
+	subscriptions := []subscription{}
+	for _, follow := range feedFollows {
+		followCategories := categories[follow.FeedID]
+		if followCategories == nil {
+			followCategories = []category{}
+		}
+		subscriptions = append(subscriptions, subscription{
+			ID:         feedStreamPrefix + follow.Url,
+			Title:      follow.Name,
+			Categories: followCategories,
+			URL:        follow.Url,
+			HTMLURL:    follow.Url,
+		})
+	}
+	s.writeJSON(w, map[string][]subscription{"subscriptions": subscriptions})
+}
+
+func (s *Server) handleSubscriptionEdit(w http.ResponseWriter, r *http.Request, userData database.User) {
+	action := r.FormValue("ac")
+	title := r.FormValue("t")
+
+	for _, streamID := range formValues(r, "s") {
+		feedURL, ok := strings.CutPrefix(streamID, feedStreamPrefix)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Invalid subscription stream '%v'", streamID), http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		switch action {
+		case "subscribe":
+			err = s.subscribe(r.Context(), userData, feedURL, title)
+			if err == nil {
+				err = s.editLabels(r, userData, feedURL)
+			}
+		case "unsubscribe":
+			err = s.unsubscribe(r.Context(), userData, feedURL)
+		case "edit":
+			err = s.editTitle(r, userData, feedURL, title)
+			if err == nil {
+				err = s.editLabels(r, userData, feedURL)
+			}
+		default:
+			http.Error(w, fmt.Sprintf("Unknown subscription action '%v'", action), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+	}
+	writeOK(w)
+}
+
+func (s *Server) handleQuickAdd(w http.ResponseWriter, r *http.Request, userData database.User) {
+	feedURL := strings.TrimPrefix(r.FormValue("quickadd"), feedStreamPrefix)
+	if feedURL == "" {
+		http.Error(w, "Missing quickadd parameter", http.StatusBadRequest)
+		return
+	}
+
+	err := s.subscribe(r.Context(), userData, feedURL, "")
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, map[string]any{
+		"numResults": 1,
+		"query":      feedURL,
+		"streamId":   feedStreamPrefix + feedURL,
+	})
+}
+
+// subscribe makes the user follow feedURL, creating the feed first if no
+// one has added it yet. Following an already followed feed is a no-op.
+func (s *Server) subscribe(ctx context.Context, userData database.User, feedURL, title string) error {
+	feedData, err := s.db.GetFeedFromURL(ctx, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = rss.ParseFeedURL(feedURL)
+		if err != nil {
+			return err
+		}
+		if title == "" {
+			title = feedURL
+		}
+		feedData, err = s.db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			Name:      title,
+			Url:       feedURL,
+			UserID:    uuid.NullUUID{UUID: userData.ID, Valid: true},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting the feed data: %v", err)
+	}
+
+	feedFollows, err := s.db.GetFeedFollowsForUser(ctx, userData.ID)
+	if err != nil {
+		return fmt.Errorf("Error fetching follow data: %v", err)
+	}
+	for _, follow := range feedFollows {
+		if follow.FeedID == feedData.ID {
+			return nil
+		}
+	}
+
+	_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userData.ID,
+		FeedID:    feedData.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("Error creating follow in the database: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) unsubscribe(ctx context.Context, userData database.User, feedURL string) error {
+	feedData, err := s.db.GetFeedFromURL(ctx, feedURL)
+	if err != nil {
+		return err
+	}
+	return s.db.DeleteFollow(ctx, database.DeleteFollowParams{
+		UserID: userData.ID,
+		FeedID: feedData.ID,
+	})
+}
+
+// editTitle stores title as the user's own name for the feed. Clients
+// send no t parameter when only the folders change.
+func (s *Server) editTitle(r *http.Request, userData database.User, feedURL, title string) error {
+	if title == "" {
+		return nil
+	}
+	feedData, err := s.db.GetFeedFromURL(r.Context(), feedURL)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.SetFeedFollowTitle(r.Context(), database.SetFeedFollowTitleParams{
+		UserID:    userData.ID,
+		FeedID:    feedData.ID,
+		Title:     sql.NullString{String: title, Valid: true},
+		UpdatedAt: time.Now(),
+	})
+	return err
+}
+
+// editLabels applies the labels a subscription/edit request adds (a) and
+// removes (r) to the user's follow of feedURL.
+func (s *Server) editLabels(r *http.Request, userData database.User, feedURL string) error {
+	addLabels := formValues(r, "a")
+	removeLabels := formValues(r, "r")
+	if len(addLabels) == 0 && len(removeLabels) == 0 {
+		return nil
+	}
+
+	feedData, err := s.db.GetFeedFromURL(r.Context(), feedURL)
+	if err != nil {
+		return err
+	}
+	follow, err := s.db.GetFeedFollow(r.Context(), database.GetFeedFollowParams{
+		UserID: userData.ID,
+		FeedID: feedData.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, label := range addLabels {
+		if labelName(label) == "" {
+			continue
+		}
+		err = s.db.AddFeedFollowTag(r.Context(), database.AddFeedFollowTagParams{
+			FeedFollowID: follow.ID,
+			Tag:          labelName(label),
+			CreatedAt:    time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, label := range removeLabels {
+		if labelName(label) == "" {
+			continue
+		}
+		_, err = s.db.RemoveFeedFollowTag(r.Context(), database.RemoveFeedFollowTagParams{
+			FeedFollowID: follow.ID,
+			Tag:          labelName(label),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
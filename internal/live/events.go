@@ -0,0 +1,121 @@
+package live
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/auth"
+	"github.com/Mr-Rafael/gator/internal/database"
+)
+
+const heartbeatInterval = 30 * time.Second
+
+type postEvent struct {
+	ItemID      int64      `json:"item_id"`
+	FeedName    string     `json:"feed_name"`
+	FeedURL     string     `json:"feed_url"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// Server streams the posts that land in the feeds a user follows as
+// Server-Sent Events. Clients authenticate with the API token from
+// ClientLogin, either in the Authorization header or, since EventSource
+// cannot set headers, in the "auth" query parameter.
+type Server struct {
+	db     database.Querier
+	hub    *Hub
+	logger *slog.Logger
+}
+
+func NewServer(db database.Querier, hub *Hub, logger *slog.Logger) *Server {
+	return &Server{
+		db:     db,
+		hub:    hub,
+		logger: logger,
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("auth")
+	if header, ok := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth="); ok {
+		token = header
+	}
+	userData, err := auth.UserFromToken(r.Context(), s.db, token)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	posts := s.hub.Subscribe()
+	defer s.hub.Unsubscribe(posts)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case newPost := <-posts:
+			// Only posts from followed feeds are found for the user.
+			post, err := s.db.GetPostForUserByItemID(r.Context(), database.GetPostForUserByItemIDParams{
+				UserID: userData.ID,
+				ItemID: newPost.ItemID,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				s.logger.Error("Error getting new post", "item_id", newPost.ItemID, "error", err)
+				continue
+			}
+			// The user's rules have muted it.
+			if post.Muted {
+				continue
+			}
+
+			event := postEvent{
+				ItemID:      post.ItemID,
+				FeedName:    post.FeedName,
+				FeedURL:     post.FeedUrl,
+				Title:       post.Title,
+				URL:         post.Url,
+				Description: post.Description.String,
+			}
+			if post.PublishedAt.Valid {
+				event.PublishedAt = &post.PublishedAt.Time
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				s.logger.Error("Error encoding new post", "item_id", newPost.ItemID, "error", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: post\nid: %v\ndata: %s\n\n", post.ItemID, data)
+			flusher.Flush()
+		}
+	}
+}
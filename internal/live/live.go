@@ -0,0 +1,133 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Channel is the PostgreSQL NOTIFY channel the aggregator announces newly
+// stored posts on.
+const Channel = "gator_new_posts"
+
+// NewPost is the NOTIFY payload sent for every post inserted by the aggregator.
+type NewPost struct {
+	ItemID int64     `json:"item_id"`
+	FeedID uuid.UUID `json:"feed_id"`
+}
+
+// Hub fans out new post notifications to every connected subscriber.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[chan NewPost]struct{}
+	logger      *slog.Logger
+}
+
+func NewHub(logger *slog.Logger) *Hub {
+	return &Hub{
+		subscribers: make(map[chan NewPost]struct{}),
+		logger:      logger,
+	}
+}
+
+func (h *Hub) Subscribe() chan NewPost {
+	ch := make(chan NewPost, 16)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *Hub) Unsubscribe(ch chan NewPost) {
+	h.mu.Lock()
+	delete(h.subscribers, ch)
+	h.mu.Unlock()
+}
+
+// Publish hands the post to every subscriber. Slow subscribers miss the
+// event rather than stall the others.
+func (h *Hub) Publish(post NewPost) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- post:
+		default:
+		}
+	}
+}
+
+// Listen relays notifications from the database until ctx is cancelled.
+func (h *Hub) Listen(ctx context.Context, dbURL string) error {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			h.logger.Error("Error in the database listener", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(Channel)
+	if err != nil {
+		return fmt.Errorf("Error listening for new posts: %v", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established
+			// and some notifications may have been lost.
+			if notification == nil {
+				continue
+			}
+			var post NewPost
+			err := json.Unmarshal([]byte(notification.Extra), &post)
+			if err != nil {
+				h.logger.Error("Error decoding new post notification", "error", err)
+				continue
+			}
+			h.Publish(post)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+// Poll relays the posts stored since it started by checking for new item
+// ids every interval, for databases without LISTEN/NOTIFY.
+func (h *Hub) Poll(ctx context.Context, db database.Querier, interval time.Duration) error {
+	lastItemID, err := db.GetLatestItemID(ctx)
+	if err != nil {
+		return fmt.Errorf("Error reading the latest post: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			posts, err := db.GetPostsAfterItemID(ctx, lastItemID)
+			if err != nil {
+				h.logger.Error("Error polling for new posts", "error", err)
+				continue
+			}
+			for _, post := range posts {
+				h.Publish(NewPost{
+					ItemID: post.ItemID,
+					FeedID: post.FeedID,
+				})
+				lastItemID = post.ItemID
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package logging builds the logger diagnostics are written to, kept apart
+// from the results commands print.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	DefaultLevel  = "info"
+	DefaultFormat = "text"
+)
+
+// New returns a logger writing records at level or above to w. The level
+// is one of debug, info, warn or error, and the format text or json.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var logLevel slog.Level
+	err := logLevel.UnmarshalText([]byte(level))
+	if err != nil {
+		return nil, fmt.Errorf("Error: unknown log level '%v' (expected debug, info, warn or error)", level)
+	}
+	options := &slog.HandlerOptions{Level: logLevel}
+
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("Error: unknown log format '%v' (expected text or json)", format)
+	}
+}
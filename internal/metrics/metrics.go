@@ -0,0 +1,97 @@
+// Package metrics holds the aggregator's Prometheus metrics and the
+// handler that exposes them.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultOverdueAfter is how long a feed can stay due before it counts
+// as overdue when the config doesn't say.
+const DefaultOverdueAfter = time.Hour
+
+// The outcomes fetches are counted by.
+const (
+	OutcomeSuccess      = "success"
+	OutcomeNetworkError = "network_error"
+	OutcomeHTTPError    = "http_error"
+	OutcomeRejected     = "rejected"
+	OutcomeParseError   = "parse_error"
+	OutcomeStoreError   = "store_error"
+)
+
+var (
+	FeedFetches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gator_feed_fetches_total",
+		Help: "Feed fetches, by outcome.",
+	}, []string{"outcome"})
+	FetchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "gator_feed_fetch_duration_seconds",
+		Help:    "Time taken to fetch a feed and store its posts.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	})
+	FetchedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gator_feed_fetched_bytes_total",
+		Help: "Bytes downloaded from feeds.",
+	})
+	PostsInserted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gator_posts_inserted_total",
+		Help: "New posts stored from feeds.",
+	})
+	FeedsOverdue = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gator_feeds_overdue",
+		Help: "Feeds due for longer than the overdue threshold.",
+	})
+	DBQueryErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gator_db_query_errors_total",
+		Help: "Database queries that failed.",
+	})
+
+	registry = prometheus.NewRegistry()
+)
+
+func init() {
+	registry.MustRegister(
+		FeedFetches,
+		FetchDuration,
+		FetchedBytes,
+		PostsInserted,
+		FeedsOverdue,
+		DBQueryErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	// Export every outcome from the start, so rates work before the first
+	// failure.
+	for _, outcome := range []string{OutcomeSuccess, OutcomeNetworkError, OutcomeHTTPError, OutcomeRejected, OutcomeParseError, OutcomeStoreError} {
+		FeedFetches.WithLabelValues(outcome)
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// OverdueAfter returns how long a feed can stay due before it counts
+// as overdue.
+func OverdueAfter(conf config.MetricsConfig) (time.Duration, error) {
+	if conf.OverdueAfter == "" {
+		return DefaultOverdueAfter, nil
+	}
+	overdueAfter, err := time.ParseDuration(conf.OverdueAfter)
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing the metrics overdue_after: %v", err)
+	}
+	if overdueAfter <= 0 {
+		return 0, fmt.Errorf("Error: the metrics overdue_after must be positive")
+	}
+	return overdueAfter, nil
+}
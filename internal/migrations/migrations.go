@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Mr-Rafael/gator/internal/storage"
+	"github.com/Mr-Rafael/gator/sql/schema"
+	"github.com/pressly/goose/v3"
+)
+
+// NewProvider returns a goose provider over the embedded schema of the
+// database's backend. It keeps its bookkeeping in goose_db_version, the
+// table the goose CLI uses, so databases migrated by hand are picked up
+// where they were left.
+func NewProvider(db *storage.DB) (*goose.Provider, error) {
+	var provider *goose.Provider
+	var err error
+	switch db.Dialect {
+	case storage.SQLite:
+		provider, err = goose.NewProvider(goose.DialectSQLite3, db.Conn, schema.SQLite())
+	default:
+		provider, err = goose.NewProvider(goose.DialectPostgres, db.Conn, schema.Postgres)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error loading the migrations: %v", err)
+	}
+	return provider, nil
+}
+
+// Check returns an error explaining what to do when the database schema
+// is not the one this build of gator was written against.
+func Check(ctx context.Context, db *storage.DB) error {
+	provider, err := NewProvider(db)
+	if err != nil {
+		return err
+	}
+	// GetDBVersion creates the version table on a new database, which then
+	// reports version 0 instead of failing.
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("Error reading the database schema version: %v", err)
+	}
+	target := LatestVersion(provider)
+	if current < target {
+		return fmt.Errorf("The database schema is at version %v, but this gator needs version %v. Run 'gator migrate up' to update it", current, target)
+	}
+	if current > target {
+		return fmt.Errorf("The database schema is at version %v, newer than the version %v this gator knows. Update gator before using this database", current, target)
+	}
+	return nil
+}
+
+func LatestVersion(provider *goose.Provider) int64 {
+	sources := provider.ListSources()
+	if len(sources) == 0 {
+		return 0
+	}
+	return sources[len(sources)-1].Version
+}
@@ -0,0 +1,157 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    head     `xml:"head"`
+	Body    body     `xml:"body"`
+}
+
+type head struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type body struct {
+	Outlines []outline `xml:"outline"`
+}
+
+type outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Category string    `xml:"category,attr,omitempty"`
+	Outlines []outline `xml:"outline"`
+}
+
+// Subscription is a followed feed as stored in an OPML file.
+type Subscription struct {
+	Title string
+	URL   string
+	Tags  []string
+}
+
+// Write exports subscriptions as OPML 2.0. Tags are written to the
+// category attribute; tagged feeds are also nested in a folder named after
+// their first tag, for readers that only understand folders.
+func Write(w io.Writer, title string, subscriptions []Subscription) error {
+	doc := document{
+		Version: "2.0",
+		Head: head{
+			Title:       title,
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := map[string]int{}
+	for _, subscription := range subscriptions {
+		feedOutline := outline{
+			Text:     subscription.Title,
+			Title:    subscription.Title,
+			Type:     "rss",
+			XMLURL:   subscription.URL,
+			HTMLURL:  subscription.URL,
+			Category: strings.Join(subscription.Tags, ","),
+		}
+		if len(subscription.Tags) == 0 {
+			doc.Body.Outlines = append(doc.Body.Outlines, feedOutline)
+			continue
+		}
+
+		folder := subscription.Tags[0]
+		i, ok := folders[folder]
+		if !ok {
+			i = len(doc.Body.Outlines)
+			folders[folder] = i
+			doc.Body.Outlines = append(doc.Body.Outlines, outline{Text: folder, Title: folder})
+		}
+		doc.Body.Outlines[i].Outlines = append(doc.Body.Outlines[i].Outlines, feedOutline)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("Error encoding the OPML document: %v", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// Read parses an OPML file into its subscriptions. A feed's tags are the
+// entries of its category attribute plus the folders it is nested in.
+func Read(r io.Reader) ([]Subscription, error) {
+	var doc document
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing the OPML document: %v", err)
+	}
+
+	subscriptions := []Subscription{}
+	var walk func(outlines []outline, folders []string)
+	walk = func(outlines []outline, folders []string) {
+		for _, o := range outlines {
+			name := o.Title
+			if name == "" {
+				name = o.Text
+			}
+			if o.XMLURL == "" {
+				walk(o.Outlines, append(folders, name))
+				continue
+			}
+
+			tags := []string{}
+			for _, tag := range append(append([]string{}, folders...), parseCategory(o.Category)...) {
+				if tag != "" && !contains(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			if name == "" {
+				name = o.XMLURL
+			}
+			subscriptions = append(subscriptions, Subscription{
+				Title: name,
+				URL:   o.XMLURL,
+				Tags:  tags,
+			})
+		}
+	}
+	walk(doc.Body.Outlines, nil)
+	return subscriptions, nil
+}
+
+// parseCategory splits a category attribute, which OPML defines as a comma
+// separated list of slash delimited paths ("/Tech/Go,News").
+func parseCategory(category string) []string {
+	tags := []string{}
+	for _, entry := range strings.Split(category, ",") {
+		tag := strings.Trim(strings.TrimSpace(entry), "/")
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package retention
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+	"github.com/Mr-Rafael/gator/internal/database"
+)
+
+const (
+	// DefaultInterval is how often agg prunes when the config doesn't say.
+	DefaultInterval = time.Hour
+	// DefaultMaxFetches is how many fetches of each feed the fetch log
+	// keeps when the config doesn't say.
+	DefaultMaxFetches = 100
+)
+
+// Policy says which posts of a feed are kept: the ones stored within
+// MaxAge, and the MaxPosts latest. A zero limit keeps posts regardless of
+// it. Starred posts are always kept.
+type Policy struct {
+	MaxAge   time.Duration
+	MaxPosts int
+}
+
+func FromConfig(conf config.RetentionConfig) (Policy, error) {
+	policy := Policy{MaxPosts: conf.MaxPostsPerFeed}
+	if conf.MaxAge != "" {
+		maxAge, err := time.ParseDuration(conf.MaxAge)
+		if err != nil {
+			return Policy{}, fmt.Errorf("Error parsing the retention max_age: %v", err)
+		}
+		policy.MaxAge = maxAge
+	}
+	if policy.MaxAge < 0 || policy.MaxPosts < 0 {
+		return Policy{}, fmt.Errorf("Error: the retention limits can't be negative")
+	}
+	return policy, nil
+}
+
+// Interval returns how often agg should prune.
+func Interval(conf config.RetentionConfig) (time.Duration, error) {
+	if conf.Interval == "" {
+		return DefaultInterval, nil
+	}
+	interval, err := time.ParseDuration(conf.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing the retention interval: %v", err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("Error: the retention interval must be positive")
+	}
+	return interval, nil
+}
+
+// MaxFetches returns how many fetches of each feed the fetch log keeps.
+func MaxFetches(conf config.RetentionConfig) int {
+	if conf.MaxFetchesPerFeed <= 0 {
+		return DefaultMaxFetches
+	}
+	return conf.MaxFetchesPerFeed
+}
+
+// ForFeed applies the feed's own limits over the default policy.
+func ForFeed(defaults Policy, feed database.Feed) Policy {
+	policy := defaults
+	if feed.RetentionMaxAgeSeconds.Valid {
+		policy.MaxAge = time.Duration(feed.RetentionMaxAgeSeconds.Int64) * time.Second
+	}
+	if feed.RetentionMaxPosts.Valid {
+		policy.MaxPosts = int(feed.RetentionMaxPosts.Int32)
+	}
+	return policy
+}
+
+func (p Policy) KeepsEverything() bool {
+	return p.MaxAge == 0 && p.MaxPosts == 0
+}
+
+// OlderThan returns the cutoff before which posts stored are pruned, if
+// the policy limits their age.
+func (p Policy) OlderThan(now time.Time) (time.Time, bool) {
+	if p.MaxAge == 0 {
+		return time.Time{}, false
+	}
+	return now.Add(-p.MaxAge), true
+}
+
+func (p Policy) String() string {
+	if p.KeepsEverything() {
+		return "keep everything"
+	}
+	limits := []string{}
+	if p.MaxAge > 0 {
+		limits = append(limits, fmt.Sprintf("keep %v", p.MaxAge))
+	}
+	if p.MaxPosts > 0 {
+		limits = append(limits, fmt.Sprintf("keep the latest %v posts", p.MaxPosts))
+	}
+	return strings.Join(limits, ", ")
+}
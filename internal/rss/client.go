@@ -0,0 +1,160 @@
+package rss
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+)
+
+const (
+	DefaultTimeout        = 30 * time.Second
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultMaxRedirects   = 10
+)
+
+// Version is gator's version in the User-Agent. Builds can set it with
+// -ldflags "-X github.com/Mr-Rafael/gator/internal/rss.Version=v1.2.3";
+// otherwise it's the module version go install recorded.
+var Version = ""
+
+// newClient returns the HTTP client feeds are fetched with, along with the
+// User-Agent it sends.
+func newClient(conf config.FetchConfig) (*http.Client, string, error) {
+	transport, err := NewTransport(conf)
+	if err != nil {
+		return nil, "", err
+	}
+	timeout, err := parseTimeout("timeout", conf.Timeout, DefaultTimeout)
+	if err != nil {
+		return nil, "", err
+	}
+	if conf.MaxRedirects < 0 {
+		return nil, "", fmt.Errorf("Error: the fetch max_redirects can't be negative")
+	}
+	maxRedirects := DefaultMaxRedirects
+	if conf.MaxRedirects > 0 {
+		maxRedirects = conf.MaxRedirects
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %v redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+	return client, userAgent(conf), nil
+}
+
+// NewTransport returns the transport for requests to the urls users give
+// gator, set up by the fetch config: it connects through the configured
+// proxy, trusts the CA bundle, and keeps away from internal addresses
+// unless they are allowed.
+func NewTransport(conf config.FetchConfig) (*http.Transport, error) {
+	connectTimeout, err := parseTimeout("connect_timeout", conf.ConnectTimeout, DefaultConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	allowHosts := slices.Clone(conf.AllowHosts)
+	proxy := http.ProxyFromEnvironment
+	if conf.Proxy != "" {
+		proxyURL, err := url.Parse(conf.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("Error: the fetch proxy must be a url such as http://proxy:3128")
+		}
+		proxy = http.ProxyURL(proxyURL)
+		// The configured proxy may run anywhere, since the hosts it's
+		// asked for are checked before each request.
+		allowHosts = append(allowHosts, proxyURL.Hostname())
+	}
+	guard, err := newAddressGuard(allowHosts, conf.AllowNetworks)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.Proxy = guard.proxy(proxy)
+	open := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	guarded := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   guard.control,
+	}
+	transport.DialContext = guard.dialContext(guarded, open)
+	if conf.CABundle != "" {
+		rootCAs, err := loadCABundle(conf.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+	return transport, nil
+}
+
+func parseTimeout(name, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("Error: the fetch %v expects a positive duration such as 30s", name)
+	}
+	return timeout, nil
+}
+
+// loadCABundle returns the system's certificate pool with the certificates
+// in the PEM file at path added.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the fetch ca_bundle: %v", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("Error: the fetch ca_bundle has no PEM certificates")
+	}
+	return pool, nil
+}
+
+// userAgent returns the configured User-Agent, or else one naming gator's
+// version and the contact url, if there is one.
+func userAgent(conf config.FetchConfig) string {
+	if conf.UserAgent != "" {
+		return conf.UserAgent
+	}
+	agent := "gator/" + version()
+	if conf.ContactURL != "" {
+		agent += " (+" + conf.ContactURL + ")"
+	}
+	return agent
+}
+
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
@@ -0,0 +1,121 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// DefaultMaxBodyBytes caps the size of a feed when the config doesn't.
+	DefaultMaxBodyBytes = 10 << 20
+	// MaxElementDepth is the deepest a feed may nest its elements. Real
+	// feeds stay within a handful of levels.
+	MaxElementDepth = 64
+	// snippetBytes is how much of a feed that fails to parse is quoted in
+	// the error.
+	snippetBytes = 200
+)
+
+var (
+	// ErrFeedTooLarge is wrapped by the errors of feeds over the size
+	// limit.
+	ErrFeedTooLarge = errors.New("the feed is too large")
+	// ErrUnsafeFeed is wrapped by the errors of feeds the XML guards
+	// refuse, for declaring entities or nesting elements too deeply.
+	ErrUnsafeFeed = errors.New("the feed is unsafe to parse")
+)
+
+// bodyReader reads a response body up to a limit, counting the bytes read
+// and keeping the first few for error messages. It remembers the error
+// that stopped the reading, so it can be told from a parse error.
+type bodyReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+	head  []byte
+	err   error
+}
+
+func newBodyReader(r io.Reader, limit int64) *bodyReader {
+	return &bodyReader{r: r, limit: limit}
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	// Read one byte past the limit, to tell a body of exactly the limit
+	// from a larger one.
+	if remaining := b.limit + 1 - b.n; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	if missing := snippetBytes - len(b.head); missing > 0 {
+		b.head = append(b.head, p[:min(n, missing)]...)
+	}
+	if b.n > b.limit {
+		b.err = ErrFeedTooLarge
+		return n, b.err
+	}
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+// snippet returns the start of the body, dropping any bytes that aren't
+// valid UTF-8, such as a character cut in half.
+func (b *bodyReader) snippet() string {
+	snippet := strings.ToValidUTF8(string(bytes.TrimSpace(b.head)), "")
+	if b.n > int64(len(b.head)) {
+		snippet += "..."
+	}
+	return snippet
+}
+
+// guardedTokens passes on the raw tokens of a document, refusing entity
+// declarations and elements nested deeper than MaxElementDepth. The
+// decoder reading from it checks and translates the tokens as usual.
+type guardedTokens struct {
+	decoder *xml.Decoder
+	depth   int
+}
+
+func (g *guardedTokens) Token() (xml.Token, error) {
+	token, err := g.decoder.RawToken()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case xml.StartElement:
+		g.depth++
+		if g.depth > MaxElementDepth {
+			return nil, fmt.Errorf("%w: elements are nested deeper than %v levels", ErrUnsafeFeed, MaxElementDepth)
+		}
+	case xml.EndElement:
+		g.depth--
+	case xml.Directive:
+		if strings.Contains(strings.ToUpper(string(t)), "<!ENTITY") {
+			return nil, fmt.Errorf("%w: the document declares entities, which aren't supported", ErrUnsafeFeed)
+		}
+	}
+	// Raw tokens share a buffer with the decoder, so they must be copied
+	// before the next one is read.
+	return xml.CopyToken(token), nil
+}
+
+// decodeFeed parses a feed from r as it streams in.
+func decodeFeed(r io.Reader) (*RSSFeed, error) {
+	decoder := xml.NewTokenDecoder(&guardedTokens{decoder: xml.NewDecoder(r)})
+	var feed RSSFeed
+	err := decoder.Decode(&feed)
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
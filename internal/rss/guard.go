@@ -0,0 +1,182 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrInvalidFeedURL is wrapped by the errors of ParseFeedURL.
+var ErrInvalidFeedURL = errors.New("invalid feed url")
+
+// ParseFeedURL parses a url given for a feed, which must be an http or
+// https url with a host.
+func ParseFeedURL(feedURL string) (*url.URL, error) {
+	parsedURL, err := ParseHTTPURL(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFeedURL, err)
+	}
+	return parsedURL, nil
+}
+
+// ParseHTTPURL parses a url gator is asked to send requests to, such as a
+// webhook's, which must be an http or https url with a host.
+func ParseHTTPURL(rawURL string) (*url.URL, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("'%v' isn't an http or https url", rawURL)
+	}
+	if parsedURL.Hostname() == "" {
+		return nil, fmt.Errorf("'%v' has no host", rawURL)
+	}
+	return parsedURL, nil
+}
+
+// deniedNetworks are the internal and special-purpose ranges that the
+// netip predicates miss, including the IPv6 forms embedding IPv4 addresses.
+var deniedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT, and Alibaba Cloud's metadata service
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and the broadcast address
+	netip.MustParsePrefix("::/96"),           // IPv4-compatible
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/32"),       // Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+}
+
+// addressGuard keeps fetches away from internal addresses: loopback,
+// private, link-local, multicast and unspecified ones, which include the
+// cloud metadata services, and the deniedNetworks. The hosts and networks
+// it allows are exempt.
+type addressGuard struct {
+	allowHosts    []string
+	allowNetworks []netip.Prefix
+}
+
+func newAddressGuard(allowHosts, allowNetworks []string) (*addressGuard, error) {
+	guard := &addressGuard{}
+	for _, host := range allowHosts {
+		guard.allowHosts = append(guard.allowHosts, strings.ToLower(strings.TrimSpace(host)))
+	}
+	for _, network := range allowNetworks {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(network))
+		if err != nil {
+			return nil, fmt.Errorf("Error: the fetch allow_networks expects networks such as 10.0.0.0/8, found '%v'", network)
+		}
+		guard.allowNetworks = append(guard.allowNetworks, prefix.Masked())
+	}
+	return guard, nil
+}
+
+// allowsHost tells whether host is one of the allowed hosts or one of
+// their subdomains.
+func (g *addressGuard) allowsHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range g.allowHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *addressGuard) allowsAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, network := range g.allowNetworks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, network := range deniedNetworks {
+		if network.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func blockedAddress(addr netip.Addr) error {
+	return fmt.Errorf("refusing to connect to the internal address %v (see the fetch allow_hosts and allow_networks)", addr)
+}
+
+// checkHost resolves host and checks every address it has, unless the
+// host is allowed.
+func (g *addressGuard) checkHost(ctx context.Context, host string) error {
+	if g.allowsHost(host) {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolving %v: %v", host, err)
+	}
+	for _, addr := range addrs {
+		if !g.allowsAddress(addr) {
+			return blockedAddress(addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// control runs once the dialer has resolved the address, so that a host
+// name can't point the fetch at an internal address.
+func (g *addressGuard) control(network, address string, conn syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected address '%v': %v", address, err)
+	}
+	if !g.allowsAddress(addrPort.Addr()) {
+		return blockedAddress(addrPort.Addr().Unmap())
+	}
+	return nil
+}
+
+// dialContext dials through guarded unless the host is allowed, in which
+// case it goes through open.
+func (g *addressGuard) dialContext(guarded, open *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err == nil && g.allowsHost(host) {
+			return open.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+}
+
+// proxy picks the proxy for each request with next. Through a proxy, the
+// dialer only sees the proxy's address, so the host of the request, which
+// includes each redirect, is checked here instead.
+func (g *addressGuard) proxy(next func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := next(req)
+		if err != nil || proxyURL == nil {
+			return proxyURL, err
+		}
+		err = g.checkHost(req.Context(), req.URL.Hostname())
+		if err != nil {
+			return nil, err
+		}
+		return proxyURL, nil
+	}
+}
@@ -0,0 +1,133 @@
+package rss
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+)
+
+func TestAddressGuardBlocksInternalAddresses(t *testing.T) {
+	guard, err := newAddressGuard(nil, nil)
+	if err != nil {
+		t.Fatalf("creating the guard: %v", err)
+	}
+	tests := []struct {
+		addr    string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.100.100.200", false},
+		{"192.0.0.8", false},
+		{"198.18.0.1", false},
+		{"198.19.255.255", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::127.0.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"2002:a9fe:a9fe::1", false},
+		{"2001:0:a9fe:a9fe::1", false},
+		{"ff02::1", false},
+	}
+	for _, test := range tests {
+		if allowed := guard.allowsAddress(netip.MustParseAddr(test.addr)); allowed != test.allowed {
+			t.Errorf("allowsAddress(%v) = %v, want %v", test.addr, allowed, test.allowed)
+		}
+	}
+}
+
+func TestAddressGuardAllowlists(t *testing.T) {
+	guard, err := newAddressGuard([]string{"Intranet.example.com"}, []string{"10.1.0.0/16", "fd00:1234::/32"})
+	if err != nil {
+		t.Fatalf("creating the guard: %v", err)
+	}
+	for _, addr := range []string{"10.1.2.3", "fd00:1234::1", "::ffff:10.1.0.1"} {
+		if !guard.allowsAddress(netip.MustParseAddr(addr)) {
+			t.Errorf("blocked %v, in an allowed network", addr)
+		}
+	}
+	for _, addr := range []string{"10.2.0.1", "fd00:1235::1", "127.0.0.1"} {
+		if guard.allowsAddress(netip.MustParseAddr(addr)) {
+			t.Errorf("allowed %v, outside the allowed networks", addr)
+		}
+	}
+	for _, host := range []string{"intranet.example.com", "feeds.intranet.example.com"} {
+		if !guard.allowsHost(host) {
+			t.Errorf("blocked the allowed host %v", host)
+		}
+	}
+	for _, host := range []string{"example.com", "notintranet.example.com"} {
+		if guard.allowsHost(host) {
+			t.Errorf("allowed the host %v", host)
+		}
+	}
+
+	_, err = newAddressGuard(nil, []string{"10.0.0.0"})
+	if err == nil {
+		t.Error("accepted an allowed network without a prefix length")
+	}
+}
+
+func TestProxiedRequestsAreChecked(t *testing.T) {
+	proxied := make(chan string, 2)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(proxy.Close)
+	client, _, err := newClient(config.FetchConfig{
+		Proxy:      proxy.URL,
+		AllowHosts: []string{"feeds.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("creating the client: %v", err)
+	}
+
+	get := func(target string) error {
+		req, err := http.NewRequestWithContext(context.Background(), "GET", target, nil)
+		if err != nil {
+			t.Fatalf("creating the request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	// The proxy itself runs on 127.0.0.1, but it mustn't be asked for
+	// internal addresses.
+	err = get("http://169.254.169.254/latest/meta-data/")
+	if err == nil || !strings.Contains(err.Error(), "internal address 169.254.169.254") {
+		t.Errorf("fetching the metadata service through the proxy returned %v", err)
+	}
+	err = get("http://feeds.example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("fetching an allowed host through the proxy: %v", err)
+	}
+	if target := <-proxied; target != "http://feeds.example.com/feed.xml" {
+		t.Errorf("the proxy was asked for %v", target)
+	}
+	if len(proxied) != 0 {
+		t.Errorf("the proxy was asked for %v too", <-proxied)
+	}
+}
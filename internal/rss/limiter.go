@@ -0,0 +1,112 @@
+package rss
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimits are how politely a host is fetched from: at most
+// MaxConcurrent fetches at once, started at least MinDelay apart. Zero
+// values don't limit.
+type HostLimits struct {
+	MaxConcurrent int
+	MinDelay      time.Duration
+}
+
+// HostLimiter applies HostLimits to the fetches of every goroutine sharing
+// it. A host with limits of its own shares them with its subdomains, so
+// "substack.com" limits every Substack feed together.
+type HostLimiter struct {
+	defaults  HostLimits
+	overrides map[string]HostLimits
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	slots     chan struct{}
+	nextStart time.Time
+	minDelay  time.Duration
+}
+
+func NewHostLimiter(defaults HostLimits, overrides map[string]HostLimits) *HostLimiter {
+	normalized := make(map[string]HostLimits, len(overrides))
+	for host, limits := range overrides {
+		normalized[strings.ToLower(strings.TrimPrefix(host, "."))] = limits
+	}
+	return &HostLimiter{
+		defaults:  defaults,
+		overrides: normalized,
+		hosts:     make(map[string]*hostState),
+	}
+}
+
+// Acquire waits until a fetch from host may start, and returns the func
+// to call when it's done.
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	state := l.state(host)
+	release := func() {}
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = func() { <-state.slots }
+	}
+
+	l.mu.Lock()
+	start := time.Now()
+	if state.nextStart.After(start) {
+		start = state.nextStart
+	}
+	state.nextStart = start.Add(state.minDelay)
+	l.mu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return release, nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return release, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// state returns the limits and bookkeeping of the group the host belongs
+// to: the closest parent domain with limits of its own, or the host alone.
+func (l *HostLimiter) state(host string) *hostState {
+	host = strings.ToLower(host)
+	key, limits := host, l.defaults
+	for domain := host; domain != ""; {
+		if override, ok := l.overrides[domain]; ok {
+			key, limits = domain, override
+			break
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state, ok := l.hosts[key]
+	if !ok {
+		state = &hostState{minDelay: limits.MinDelay}
+		if limits.MaxConcurrent > 0 {
+			state.slots = make(chan struct{}, limits.MaxConcurrent)
+		}
+		l.hosts[key] = state
+	}
+	return state
+}
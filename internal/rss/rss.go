@@ -5,8 +5,11 @@ import (
 	"context"
 	"io"
 	"html"
-	"encoding/xml"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
+	"github.com/Mr-Rafael/gator/internal/config"
 )
 
 type RSSFeed struct {
@@ -15,6 +18,13 @@ type RSSFeed struct {
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
 		Item        []RSSItem `xml:"item"`
+		TTL         string    `xml:"ttl"`
+		SkipHours   []string  `xml:"skipHours>hour"`
+		SkipDays    []string  `xml:"skipDays>day"`
+
+		// The syndication module says how often the feed updates.
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
 	} `xml:"channel"`
 }
 
@@ -23,36 +33,153 @@ type RSSItem struct {
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	fmt.Printf("\nAttempting to fetch from URL |%v|\n", feedURL)
+// ItemAuthor returns the item's author, falling back to dc:creator, which
+// many feeds use instead of the RSS author element.
+func (item RSSItem) ItemAuthor() string {
+	if item.Author != "" {
+		return item.Author
+	}
+	return item.Creator
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+// FetchInfo describes how a fetch went, as far as it got: the status is
+// 0 and the header nil when no response arrived.
+type FetchInfo struct {
+	StatusCode int
+	Header http.Header
+	Bytes int64
+}
+
+const (
+	DefaultMaxConcurrentPerHost = 2
+	DefaultMinDelayPerHost = time.Second
+)
+
+// Fetcher fetches feeds with one configured HTTP client, keeping to the
+// host limits across every goroutine sharing it.
+type Fetcher struct {
+	client *http.Client
+	userAgent string
+	limiter *HostLimiter
+	maxBodyBytes int64
+}
+
+func NewFetcher(conf config.FetchConfig) (*Fetcher, error) {
+	client, userAgent, err := newClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := hostLimits(conf.PerHost, HostLimits{
+		MaxConcurrent: DefaultMaxConcurrentPerHost,
+		MinDelay: DefaultMinDelayPerHost,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("Failed to generate the request: %v", err)
+		return nil, fmt.Errorf("Error in the per_host fetch limits: %v", err)
+	}
+	if conf.MaxBodyBytes < 0 {
+		return nil, fmt.Errorf("Error: the fetch max_body_bytes can't be negative")
+	}
+	maxBodyBytes := int64(DefaultMaxBodyBytes)
+	if conf.MaxBodyBytes > 0 {
+		maxBodyBytes = conf.MaxBodyBytes
+	}
+	overrides := make(map[string]HostLimits, len(conf.Hosts))
+	for host, hostConf := range conf.Hosts {
+		overrides[host], err = hostLimits(hostConf, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("Error in the fetch limits of '%v': %v", host, err)
+		}
+	}
+	return &Fetcher{
+		client: client,
+		userAgent: userAgent,
+		limiter: NewHostLimiter(defaults, overrides),
+		maxBodyBytes: maxBodyBytes,
+	}, nil
+}
+
+// hostLimits reads the limits in conf, taking the ones left out from
+// fallback.
+func hostLimits(conf config.HostLimitConfig, fallback HostLimits) (HostLimits, error) {
+	limits := fallback
+	if conf.MaxConcurrent < 0 {
+		return HostLimits{}, fmt.Errorf("max_concurrent can't be negative")
 	}
-	req.Header.Set("User-Agent", "gator")
-	client := &http.Client{}
+	if conf.MaxConcurrent > 0 {
+		limits.MaxConcurrent = conf.MaxConcurrent
+	}
+	if conf.MinDelay != "" {
+		minDelay, err := time.ParseDuration(conf.MinDelay)
+		if err != nil || minDelay < 0 {
+			return HostLimits{}, fmt.Errorf("min_delay expects a duration such as 1s")
+		}
+		limits.MinDelay = minDelay
+	}
+	return limits, nil
+}
 
-	resp, err := client.Do(req)
+// FetchFeed downloads and parses the feed at feedURL, logging the request
+// to logger, which is expected to name the feed. It waits for the host's
+// limits first.
+func (f *Fetcher) FetchFeed(ctx context.Context, logger *slog.Logger, feedURL string) (*RSSFeed, FetchInfo, error) {
+	info := FetchInfo{}
+	parsedURL, err := ParseFeedURL(feedURL)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to fetch the feed from the url: %v", err)
+		return nil, info, fmt.Errorf("Failed to parse the url: %v", err)
 	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("Failed to fetch the feed from the url. Status code: %v", resp.Status)
+	waitStart := time.Now()
+	release, err := f.limiter.Acquire(ctx, parsedURL.Hostname())
+	if err != nil {
+		return nil, info, fmt.Errorf("Failed waiting for the host's fetch limits: %v", err)
 	}
-	defer resp.Body.Close()
+	defer release()
+	waited := time.Since(waitStart)
+	if waited >= time.Millisecond {
+		logger.Debug("Waited for the host's fetch limits", "waited", waited.String())
+	}
+	logger.Debug("Attempting to fetch the feed")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, info, fmt.Errorf("Failed to generate the request: %v", err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
 
-	responseBytes, err := io.ReadAll(resp.Body)
+	resp, err := f.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to read the body of the response: %v", err)
+		return nil, info, fmt.Errorf("Failed to fetch the feed from the url: %v", err)
+	}
+	defer resp.Body.Close()
+	info.StatusCode = resp.StatusCode
+	info.Header = resp.Header
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, info, fmt.Errorf("Failed to fetch the feed from the url. Status code: %v", resp.Status)
 	}
 
-	var feed RSSFeed
-	err = xml.Unmarshal(responseBytes, &feed)
+	if resp.ContentLength > f.maxBodyBytes {
+		return nil, info, fmt.Errorf("Failed to read the body of the response: %w: it's %v bytes, over the limit of %v", ErrFeedTooLarge, resp.ContentLength, f.maxBodyBytes)
+	}
+	body := newBodyReader(resp.Body, f.maxBodyBytes)
+	feed, err := decodeFeed(body)
+	if err == nil {
+		// Count whatever trails the document, up to the limit.
+		_, err = io.Copy(io.Discard, body)
+	}
+	info.Bytes = body.n
+	logger.Debug("Received the feed", "status", resp.StatusCode, "bytes", info.Bytes)
+	if body.err == ErrFeedTooLarge {
+		return nil, info, fmt.Errorf("Failed to read the body of the response: %w: it's over the limit of %v bytes", ErrFeedTooLarge, f.maxBodyBytes)
+	}
+	if body.err != nil {
+		return nil, info, fmt.Errorf("Failed to read the body of the response: %v", body.err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("Failed to unmarshal the response: %v\nError message:\n%v", string(responseBytes), err)
+		return nil, info, fmt.Errorf("Failed to parse the feed: %w. It starts with: %q", err, body.snippet())
 	}
 
 	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
@@ -61,7 +188,12 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	for i, item := range feed.Channel.Item {
 		feed.Channel.Item[i].Title = html.UnescapeString(item.Title)
 		feed.Channel.Item[i].Description = html.UnescapeString(item.Description)
+		feed.Channel.Item[i].Author = html.UnescapeString(strings.TrimSpace(item.Author))
+		feed.Channel.Item[i].Creator = html.UnescapeString(strings.TrimSpace(item.Creator))
+		for j, category := range item.Categories {
+			feed.Channel.Item[i].Categories[j] = html.UnescapeString(strings.TrimSpace(category))
+		}
 	}
 
-	return &feed, nil
+	return feed, info, nil
 }
\ No newline at end of file
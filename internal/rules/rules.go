@@ -0,0 +1,97 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+)
+
+const (
+	ActionMute     = "mute"
+	ActionMarkRead = "mark-read"
+	ActionStar     = "star"
+	ActionTag      = "tag"
+)
+
+var Actions = []string{ActionMute, ActionMarkRead, ActionStar, ActionTag}
+
+// Matcher is a rule with its patterns compiled. A post matches when it
+// satisfies every condition the rule sets; unset conditions are ignored.
+type Matcher struct {
+	Rule        database.Rule
+	title       *regexp.Regexp
+	description *regexp.Regexp
+}
+
+// Compile builds the matcher for a rule. Patterns are regular expressions
+// matched case-insensitively, as alert patterns are.
+func Compile(rule database.Rule) (*Matcher, error) {
+	matcher := &Matcher{Rule: rule}
+	var err error
+	if rule.TitlePattern.Valid {
+		matcher.title, err = regexp.Compile("(?i)" + rule.TitlePattern.String)
+		if err != nil {
+			return nil, fmt.Errorf("Error compiling the title pattern: %v", err)
+		}
+	}
+	if rule.DescriptionPattern.Valid {
+		matcher.description, err = regexp.Compile("(?i)" + rule.DescriptionPattern.String)
+		if err != nil {
+			return nil, fmt.Errorf("Error compiling the description pattern: %v", err)
+		}
+	}
+	return matcher, nil
+}
+
+func (m *Matcher) Matches(post database.Post) bool {
+	rule := m.Rule
+	if rule.FeedID.Valid && rule.FeedID.UUID != post.FeedID {
+		return false
+	}
+	if m.title != nil && !m.title.MatchString(post.Title) {
+		return false
+	}
+	if m.description != nil && !m.description.MatchString(post.Description.String) {
+		return false
+	}
+	if rule.Author.Valid && !strings.Contains(strings.ToLower(post.Author), strings.ToLower(rule.Author.String)) {
+		return false
+	}
+	if rule.Category.Valid && !hasCategory(post, rule.Category.String) {
+		return false
+	}
+	return true
+}
+
+func ValidAction(action string) bool {
+	for _, valid := range Actions {
+		if action == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinCategories encodes categories for the posts.categories column.
+func JoinCategories(categories []string) string {
+	return strings.Join(categories, "\n")
+}
+
+// SplitCategories decodes the posts.categories column.
+func SplitCategories(categories string) []string {
+	if categories == "" {
+		return nil
+	}
+	return strings.Split(categories, "\n")
+}
+
+func hasCategory(post database.Post, category string) bool {
+	for _, postCategory := range SplitCategories(post.Categories) {
+		if strings.EqualFold(postCategory, category) {
+			return true
+		}
+	}
+	return false
+}
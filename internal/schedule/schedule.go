@@ -0,0 +1,282 @@
+// Package schedule decides when the aggregator fetches each feed next.
+package schedule
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/rss"
+)
+
+const (
+	// DefaultInterval is how often feeds are fetched when nothing else
+	// says, if the config doesn't say.
+	DefaultInterval = time.Hour
+	// DefaultMinInterval and DefaultMaxInterval bound the interval of
+	// every feed not set by the user, if the config doesn't say.
+	DefaultMinInterval = 15 * time.Minute
+	DefaultMaxInterval = 24 * time.Hour
+	// CadencePosts is how many of its latest posts a feed's cadence is
+	// learned from. Feeds with fewer than 3 dated posts have none.
+	CadencePosts = 20
+)
+
+// Where the interval of a feed comes from.
+const (
+	SourceOverride = "override"
+	SourceFeed     = "feed"
+	SourceLearned  = "learned"
+	SourceDefault  = "default"
+	SourceMinimum  = "minimum"
+	SourceMaximum  = "maximum"
+)
+
+// Policy is how often feeds are fetched when neither they nor the user
+// say, and the bounds the interval of a feed is kept within unless the
+// user sets it.
+type Policy struct {
+	Default time.Duration
+	Min     time.Duration
+	Max     time.Duration
+}
+
+func FromConfig(conf config.ScheduleConfig) (Policy, error) {
+	policy := Policy{
+		Default: DefaultInterval,
+		Min:     DefaultMinInterval,
+		Max:     DefaultMaxInterval,
+	}
+	settings := []struct {
+		name     string
+		value    string
+		interval *time.Duration
+	}{
+		{"default_interval", conf.DefaultInterval, &policy.Default},
+		{"min_interval", conf.MinInterval, &policy.Min},
+		{"max_interval", conf.MaxInterval, &policy.Max},
+	}
+	for _, setting := range settings {
+		if setting.value == "" {
+			continue
+		}
+		interval, err := time.ParseDuration(setting.value)
+		if err != nil {
+			return Policy{}, fmt.Errorf("Error parsing the schedule %v: %v", setting.name, err)
+		}
+		if interval <= 0 {
+			return Policy{}, fmt.Errorf("Error: the schedule %v must be positive", setting.name)
+		}
+		*setting.interval = interval
+	}
+	if policy.Min > policy.Max {
+		return Policy{}, fmt.Errorf("Error: the schedule min_interval is longer than its max_interval")
+	}
+	if policy.Default < policy.Min || policy.Default > policy.Max {
+		return Policy{}, fmt.Errorf("Error: the schedule default_interval is outside of its min_interval and max_interval")
+	}
+	return policy, nil
+}
+
+// Plan is when a feed is fetched next, and why.
+type Plan struct {
+	Next     time.Time
+	Interval time.Duration
+	Source   string
+}
+
+// Interval returns how often to fetch the feed, and where that comes from:
+// the user's override, or else what the feed asks for and how often it
+// posts, or else the default. All but the override are kept within the
+// policy's bounds.
+func (p Policy) Interval(feed database.Feed, hints Hints) (time.Duration, string) {
+	if feed.RefreshIntervalSeconds.Valid {
+		return time.Duration(feed.RefreshIntervalSeconds.Int64) * time.Second, SourceOverride
+	}
+	interval, source := p.Default, SourceDefault
+	if hinted, ok := hints.Interval(); ok {
+		interval, source = hinted, SourceFeed
+	}
+	// Fetching twice per usual gap between posts finds them soon enough,
+	// though never sooner than the feed asks for.
+	if hints.Cadence > 0 {
+		learned := hints.Cadence / 2
+		if source == SourceDefault || learned > interval {
+			interval, source = learned, SourceLearned
+		}
+	}
+	if interval < p.Min {
+		return p.Min, SourceMinimum
+	}
+	if interval > p.Max {
+		return p.Max, SourceMaximum
+	}
+	return interval, source
+}
+
+// Plan returns when to fetch the feed after fetching it at now.
+func (p Policy) Plan(feed database.Feed, hints Hints, now time.Time) Plan {
+	interval, source := p.Interval(feed, hints)
+	return Plan{
+		Next:     hints.Next(now, interval),
+		Interval: interval,
+		Source:   source,
+	}
+}
+
+// Cadence returns the usual time between a feed's posts, from when its
+// latest posts were published, newest first: the median gap between them,
+// or the time since the latest one when the feed has gone quiet for
+// longer.
+func Cadence(published []time.Time, now time.Time) time.Duration {
+	if len(published) < 3 {
+		return 0
+	}
+	gaps := make([]time.Duration, 0, len(published)-1)
+	for i := 1; i < len(published); i++ {
+		gaps = append(gaps, published[i-1].Sub(published[i]))
+	}
+	slices.Sort(gaps)
+	cadence := gaps[len(gaps)/2]
+	return max(cadence, now.Sub(published[0]))
+}
+
+// Hints are what is known about how often a feed is worth fetching: what
+// it and the response it came in say, and how often it posts. Zero
+// intervals say nothing.
+type Hints struct {
+	// TTL is how long the channel's <ttl> says it can be cached.
+	TTL time.Duration
+	// UpdateInterval is sy:updatePeriod divided by sy:updateFrequency.
+	UpdateInterval time.Duration
+	// CacheFor is how long the Cache-Control or Expires headers say the
+	// response stays fresh.
+	CacheFor time.Duration
+	// SkipHours and SkipDays are the hours (in UTC) and days the feed asks
+	// not to be fetched in.
+	SkipHours [24]bool
+	SkipDays  [7]bool
+	// Cadence is the usual time between the feed's posts.
+	Cadence time.Duration
+}
+
+var updatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// FromFeed reads the hints of a feed received at now. Values that don't
+// parse are ignored.
+func FromFeed(feed *rss.RSSFeed, header http.Header, now time.Time) Hints {
+	hints := Hints{}
+	channel := feed.Channel
+
+	ttl, err := strconv.Atoi(strings.TrimSpace(channel.TTL))
+	if err == nil && ttl > 0 {
+		hints.TTL = time.Duration(ttl) * time.Minute
+	}
+
+	period := strings.ToLower(strings.TrimSpace(channel.UpdatePeriod))
+	frequency := strings.TrimSpace(channel.UpdateFrequency)
+	if period != "" || frequency != "" {
+		if period == "" {
+			period = "daily"
+		}
+		updates := 1
+		if frequency != "" {
+			updates, err = strconv.Atoi(frequency)
+			if err != nil {
+				updates = 0
+			}
+		}
+		if updatePeriods[period] > 0 && updates > 0 {
+			hints.UpdateInterval = updatePeriods[period] / time.Duration(updates)
+		}
+	}
+
+	for _, hour := range channel.SkipHours {
+		h, err := strconv.Atoi(strings.TrimSpace(hour))
+		if err == nil && h >= 0 && h <= 24 {
+			hints.SkipHours[h%24] = true
+		}
+	}
+	for _, day := range channel.SkipDays {
+		weekday, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]
+		if ok {
+			hints.SkipDays[weekday] = true
+		}
+	}
+
+	hints.CacheFor = cacheFor(header, now)
+	return hints
+}
+
+// cacheFor reads the Cache-Control max-age, falling back to Expires when
+// there isn't one.
+func cacheFor(header http.Header, now time.Time) time.Duration {
+	if header == nil {
+		return 0
+	}
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		switch name {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil || seconds <= 0 {
+				return 0
+			}
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	expires, err := http.ParseTime(header.Get("Expires"))
+	if err != nil || !expires.After(now) {
+		return 0
+	}
+	return expires.Sub(now)
+}
+
+// Interval returns the longest interval the feed asks for.
+func (h Hints) Interval() (time.Duration, bool) {
+	interval := max(h.TTL, h.UpdateInterval, h.CacheFor)
+	return interval, interval > 0
+}
+
+// Next returns the time interval after now, moved on to the next hour the
+// feed doesn't ask to skip. Feeds asking to skip every hour are fetched
+// regardless.
+func (h Hints) Next(now time.Time, interval time.Duration) time.Time {
+	next := now.Add(interval)
+	candidate := next
+	for i := 0; i < 7*24; i++ {
+		if !h.skips(candidate) {
+			return candidate
+		}
+		candidate = candidate.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next
+}
+
+func (h Hints) skips(t time.Time) bool {
+	t = t.UTC()
+	return h.SkipHours[t.Hour()] || h.SkipDays[t.Weekday()]
+}
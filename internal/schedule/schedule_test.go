@@ -0,0 +1,155 @@
+package schedule
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config.ScheduleConfig
+		want    Policy
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: Policy{Default: DefaultInterval, Min: DefaultMinInterval, Max: DefaultMaxInterval},
+		},
+		{
+			name: "all set",
+			conf: config.ScheduleConfig{DefaultInterval: "30m", MinInterval: "5m", MaxInterval: "12h"},
+			want: Policy{Default: 30 * time.Minute, Min: 5 * time.Minute, Max: 12 * time.Hour},
+		},
+		{
+			name: "default equal to the bounds",
+			conf: config.ScheduleConfig{DefaultInterval: "1h", MinInterval: "1h", MaxInterval: "1h"},
+			want: Policy{Default: time.Hour, Min: time.Hour, Max: time.Hour},
+		},
+		{name: "unparsable", conf: config.ScheduleConfig{DefaultInterval: "often"}, wantErr: true},
+		{name: "negative", conf: config.ScheduleConfig{MinInterval: "-1m"}, wantErr: true},
+		{name: "zero", conf: config.ScheduleConfig{MaxInterval: "0s"}, wantErr: true},
+		{name: "min over max", conf: config.ScheduleConfig{MinInterval: "2h", MaxInterval: "1h", DefaultInterval: "1h"}, wantErr: true},
+		{name: "default under min", conf: config.ScheduleConfig{DefaultInterval: "10m"}, wantErr: true},
+		{name: "min over the default default", conf: config.ScheduleConfig{MinInterval: "2h"}, wantErr: true},
+		{name: "default over max", conf: config.ScheduleConfig{DefaultInterval: "48h"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := FromConfig(test.conf)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("accepted %+v as %+v", test.conf, policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromConfig(%+v): %v", test.conf, err)
+			}
+			if policy != test.want {
+				t.Errorf("FromConfig(%+v) = %+v, want %+v", test.conf, policy, test.want)
+			}
+		})
+	}
+}
+
+func TestCadence(t *testing.T) {
+	now := time.Date(2024, 5, 6, 10, 30, 0, 0, time.UTC)
+	ago := func(hours ...int) []time.Time {
+		published := []time.Time{}
+		for _, h := range hours {
+			published = append(published, now.Add(-time.Duration(h)*time.Hour))
+		}
+		return published
+	}
+	tests := []struct {
+		name      string
+		published []time.Time
+		want      time.Duration
+	}{
+		{"no posts", nil, 0},
+		{"too few posts", ago(0, 1), 0},
+		{"median gap", ago(0, 1, 3, 6), 2 * time.Hour},
+		{"even gaps", ago(1, 2, 3, 4), time.Hour},
+		{"gone quiet", ago(10, 11, 12), 10 * time.Hour},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Cadence(test.published, now); got != test.want {
+				t.Errorf("Cadence = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHintsNext(t *testing.T) {
+	// A Monday.
+	now := time.Date(2024, 5, 6, 10, 30, 0, 0, time.UTC)
+	skipHours := func(hours ...int) [24]bool {
+		skipped := [24]bool{}
+		for _, h := range hours {
+			skipped[h] = true
+		}
+		return skipped
+	}
+	everyHour := [24]bool{}
+	for h := range everyHour {
+		everyHour[h] = true
+	}
+	tests := []struct {
+		name  string
+		hints Hints
+		want  time.Time
+	}{
+		{"no skips", Hints{}, now.Add(time.Hour)},
+		{"skipped hour", Hints{SkipHours: skipHours(11)}, time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)},
+		{"skipped hours in a row", Hints{SkipHours: skipHours(11, 12)}, time.Date(2024, 5, 6, 13, 0, 0, 0, time.UTC)},
+		{"other hours skipped", Hints{SkipHours: skipHours(3, 4)}, now.Add(time.Hour)},
+		{"skipped day", Hints{SkipDays: [7]bool{time.Monday: true}}, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)},
+		{"skipped day and hour", Hints{SkipDays: [7]bool{time.Monday: true}, SkipHours: skipHours(0)}, time.Date(2024, 5, 7, 1, 0, 0, 0, time.UTC)},
+		{"every hour skipped", Hints{SkipHours: everyHour}, now.Add(time.Hour)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.hints.Next(now, time.Hour); !got.Equal(test.want) {
+				t.Errorf("Next = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCacheFor(t *testing.T) {
+	now := time.Date(2024, 5, 6, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"no header", nil, 0},
+		{"no caching headers", http.Header{}, 0},
+		{"max-age", http.Header{"Cache-Control": {"max-age=600"}}, 10 * time.Minute},
+		{"quoted max-age among others", http.Header{"Cache-Control": {`public, Max-Age="60"`}}, time.Minute},
+		{"no-cache", http.Header{"Cache-Control": {"no-cache, max-age=600"}}, 0},
+		{"no-store", http.Header{"Cache-Control": {"no-store"}}, 0},
+		{"zero max-age", http.Header{"Cache-Control": {"max-age=0"}}, 0},
+		{"bad max-age", http.Header{"Cache-Control": {"max-age=soon"}}, 0},
+		{"expires", http.Header{"Expires": {now.Add(2 * time.Hour).Format(http.TimeFormat)}}, 2 * time.Hour},
+		{"expired", http.Header{"Expires": {now.Add(-time.Hour).Format(http.TimeFormat)}}, 0},
+		{"bad expires", http.Header{"Expires": {"0"}}, 0},
+		{
+			"max-age over expires",
+			http.Header{"Cache-Control": {"max-age=60"}, "Expires": {now.Add(2 * time.Hour).Format(http.TimeFormat)}},
+			time.Minute,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cacheFor(test.header, now); got != test.want {
+				t.Errorf("cacheFor = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
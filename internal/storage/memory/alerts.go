@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateAlert(ctx context.Context, arg database.CreateAlertParams) (database.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.user(arg.UserID); !ok {
+		return database.Alert{}, errForeignKey("alerts_user_id_fkey")
+	}
+	alert := database.Alert(arg)
+	s.alerts = append(s.alerts, alert)
+	return alert, nil
+}
+
+func (s *Store) GetAlertsForUser(ctx context.Context, userID uuid.UUID) ([]database.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.alerts, func(alert database.Alert) bool { return alert.UserID == userID }), nil
+}
+
+// GetAlertsForFeed returns the alerts of every follower of the feed.
+func (s *Store) GetAlertsForFeed(ctx context.Context, feedID uuid.UUID) ([]database.GetAlertsForFeedRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetAlertsForFeedRow{}
+	for _, alert := range s.alerts {
+		if _, following := s.followed(alert.UserID, feedID); !following {
+			continue
+		}
+		user, _ := s.user(alert.UserID)
+		rows = append(rows, database.GetAlertsForFeedRow{
+			ID:        alert.ID,
+			CreatedAt: alert.CreatedAt,
+			UpdatedAt: alert.UpdatedAt,
+			UserID:    alert.UserID,
+			Pattern:   alert.Pattern,
+			Notifier:  alert.Notifier,
+			Target:    alert.Target,
+			UserName:  user.Name,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) DeleteAlert(ctx context.Context, arg database.DeleteAlertParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := remove(&s.alerts, func(alert database.Alert) bool {
+		return alert.ID == arg.ID && alert.UserID == arg.UserID
+	})
+	s.cascade()
+	return deleted, nil
+}
+
+func (s *Store) CreateAlertEvent(ctx context.Context, arg database.CreateAlertEventParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := find(s.alerts, func(alert database.Alert) bool { return alert.ID == arg.AlertID })
+	if err != nil {
+		return errForeignKey("alert_events_alert_id_fkey")
+	}
+	if _, ok := s.post(arg.PostID); !ok {
+		return errForeignKey("alert_events_post_id_fkey")
+	}
+	s.alertEvents = append(s.alertEvents, database.AlertEvent(arg))
+	return nil
+}
+
+// GetAlertEventsForUser returns the user's latest alert events first.
+func (s *Store) GetAlertEventsForUser(ctx context.Context, arg database.GetAlertEventsForUserParams) ([]database.GetAlertEventsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetAlertEventsForUserRow{}
+	for _, event := range s.alertEvents {
+		alert, err := find(s.alerts, func(alert database.Alert) bool { return alert.ID == event.AlertID })
+		if err != nil || alert.UserID != arg.UserID {
+			continue
+		}
+		post, _ := s.post(event.PostID)
+		rows = append(rows, database.GetAlertEventsForUserRow{
+			ID:        event.ID,
+			CreatedAt: event.CreatedAt,
+			AlertID:   event.AlertID,
+			PostID:    event.PostID,
+			Error:     event.Error,
+			Pattern:   alert.Pattern,
+			Notifier:  alert.Notifier,
+			PostTitle: post.Title,
+			PostUrl:   post.Url,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetAlertEventsForUserRow) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return rows[:min(len(rows), int(arg.Limit))], nil
+}
+
+func (s *Store) DeleteAlertsForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.alerts, func(alert database.Alert) bool { return alert.UserID == userID })
+	s.cascade()
+	return nil
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateFeedFetch(ctx context.Context, arg database.CreateFeedFetchParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feed(arg.FeedID); !ok {
+		return errForeignKey("feed_fetches_feed_id_fkey")
+	}
+	s.feedFetches = append(s.feedFetches, database.FeedFetch(arg))
+	return nil
+}
+
+// latestFetches returns the feed's fetches, latest first.
+func (s *Store) latestFetches(feedID uuid.UUID) []database.FeedFetch {
+	fetches := filter(s.feedFetches, func(fetch database.FeedFetch) bool { return fetch.FeedID == feedID })
+	slices.SortStableFunc(fetches, func(a, b database.FeedFetch) int { return b.StartedAt.Compare(a.StartedAt) })
+	return fetches
+}
+
+func (s *Store) GetFeedFetches(ctx context.Context, arg database.GetFeedFetchesParams) ([]database.FeedFetch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fetches := s.latestFetches(arg.FeedID)
+	return fetches[:min(len(fetches), int(arg.Limit))], nil
+}
+
+func (s *Store) TrimFeedFetches(ctx context.Context, arg database.TrimFeedFetchesParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fetches := s.latestFetches(arg.FeedID)
+	kept := fetches[:min(len(fetches), int(arg.Keep))]
+	remove(&s.feedFetches, func(fetch database.FeedFetch) bool {
+		return fetch.FeedID == arg.FeedID && !slices.ContainsFunc(kept, func(k database.FeedFetch) bool { return k.ID == fetch.ID })
+	})
+	return nil
+}
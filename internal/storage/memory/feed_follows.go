@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.FeedFollow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.user(arg.UserID); !ok {
+		return database.FeedFollow{}, errForeignKey("feed_follows_user_id_fkey")
+	}
+	if _, ok := s.feed(arg.FeedID); !ok {
+		return database.FeedFollow{}, errForeignKey("feed_follows_feed_id_fkey")
+	}
+	if _, ok := s.followed(arg.UserID, arg.FeedID); ok {
+		return database.FeedFollow{}, errUnique("feed_follows_user_id_feed_id_key")
+	}
+	follow := database.FeedFollow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+	}
+	s.feedFollows = append(s.feedFollows, follow)
+	return follow, nil
+}
+
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetFeedFollowsForUserRow{}
+	for _, follow := range s.feedFollows {
+		if follow.UserID != userID {
+			continue
+		}
+		feed, _ := s.feed(follow.FeedID)
+		rows = append(rows, database.GetFeedFollowsForUserRow{
+			UserID: follow.UserID,
+			FeedID: follow.FeedID,
+			Name:   feedTitle(follow, feed),
+			Url:    feed.Url,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) GetFeedFollow(ctx context.Context, arg database.GetFeedFollowParams) (database.FeedFollow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return find(s.feedFollows, func(follow database.FeedFollow) bool {
+		return follow.UserID == arg.UserID && follow.FeedID == arg.FeedID
+	})
+}
+
+func (s *Store) GetFollowedFeeds(ctx context.Context, userID uuid.UUID) ([]database.GetFollowedFeedsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetFollowedFeedsRow{}
+	for _, feed := range s.feeds {
+		follow, ok := s.followed(userID, feed.ID)
+		if !ok {
+			continue
+		}
+		rows = append(rows, database.GetFollowedFeedsRow{
+			ID:            feed.ID,
+			Name:          feed.Name,
+			Url:           feed.Url,
+			UserID:        feed.UserID,
+			CreatedAt:     feed.CreatedAt,
+			UpdatedAt:     feed.UpdatedAt,
+			LastFetchedAt: feed.LastFetchedAt,
+			ApiID:         feed.ApiID,
+			Title:         feedTitle(follow, feed),
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetFollowedFeedsRow) int { return strings.Compare(a.Title, b.Title) })
+	return rows, nil
+}
+
+func (s *Store) SetFeedFollowTitle(ctx context.Context, arg database.SetFeedFollowTitleParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return update(s.feedFollows, func(follow database.FeedFollow) bool {
+		return follow.UserID == arg.UserID && follow.FeedID == arg.FeedID
+	}, func(follow *database.FeedFollow) {
+		follow.Title = arg.Title
+		follow.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (s *Store) DeleteFollow(ctx context.Context, arg database.DeleteFollowParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.feedFollows, func(follow database.FeedFollow) bool {
+		return follow.UserID == arg.UserID && follow.FeedID == arg.FeedID
+	})
+	s.cascade()
+	return nil
+}
+
+func (s *Store) ResetFeedFollows(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feedFollows = nil
+	s.cascade()
+	return nil
+}
+
+func (s *Store) DeleteFeedFollowsForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.feedFollows, func(follow database.FeedFollow) bool { return follow.UserID == userID })
+	s.cascade()
+	return nil
+}
+
+func (s *Store) AddFeedFollowTag(ctx context.Context, arg database.AddFeedFollowTagParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := find(s.feedFollows, func(follow database.FeedFollow) bool { return follow.ID == arg.FeedFollowID })
+	if err != nil {
+		return errForeignKey("feed_follow_tags_feed_follow_id_fkey")
+	}
+	_, err = find(s.feedFollowTags, func(tag database.FeedFollowTag) bool {
+		return tag.FeedFollowID == arg.FeedFollowID && tag.Tag == arg.Tag
+	})
+	if err == nil {
+		return nil
+	}
+	s.feedFollowTags = append(s.feedFollowTags, database.FeedFollowTag{
+		FeedFollowID: arg.FeedFollowID,
+		Tag:          arg.Tag,
+		CreatedAt:    arg.CreatedAt,
+	})
+	return nil
+}
+
+func (s *Store) RemoveFeedFollowTag(ctx context.Context, arg database.RemoveFeedFollowTagParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return remove(&s.feedFollowTags, func(tag database.FeedFollowTag) bool {
+		return tag.FeedFollowID == arg.FeedFollowID && tag.Tag == arg.Tag
+	}), nil
+}
+
+func (s *Store) GetFeedFollowTagsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowTagsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetFeedFollowTagsForUserRow{}
+	for _, tag := range s.feedFollowTags {
+		follow, err := find(s.feedFollows, func(follow database.FeedFollow) bool { return follow.ID == tag.FeedFollowID })
+		if err != nil || follow.UserID != userID {
+			continue
+		}
+		rows = append(rows, database.GetFeedFollowTagsForUserRow{
+			FeedID: follow.FeedID,
+			Tag:    tag.Tag,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetFeedFollowTagsForUserRow) int { return strings.Compare(a.Tag, b.Tag) })
+	return rows, nil
+}
@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := find(s.feeds, func(feed database.Feed) bool { return feed.Url == arg.Url })
+	if err == nil {
+		return database.Feed{}, errUnique("feeds_url_key")
+	}
+	s.lastFeedAPIID++
+	feed := database.Feed{
+		ID:        arg.ID,
+		Name:      arg.Name,
+		Url:       arg.Url,
+		UserID:    arg.UserID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		ApiID:     s.lastFeedAPIID,
+	}
+	s.feeds = append(s.feeds, feed)
+	return feed, nil
+}
+
+func (s *Store) GetFeedFromURL(ctx context.Context, url string) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return find(s.feeds, func(feed database.Feed) bool { return feed.Url == url })
+}
+
+func (s *Store) GetFeedByAPIID(ctx context.Context, apiID int64) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return find(s.feeds, func(feed database.Feed) bool { return feed.ApiID == apiID })
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.feeds, func(feed database.Feed) bool { return feed.ID == arg.ID }, func(feed *database.Feed) {
+		feed.LastFetchedAt = arg.LastFetchedAt
+		feed.NextFetchAt = arg.NextFetchAt
+		feed.UpdatedAt = arg.LastFetchedAt.Time
+	})
+	return nil
+}
+
+func (s *Store) ScheduleFeedFetch(ctx context.Context, arg database.ScheduleFeedFetchParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.feeds, func(feed database.Feed) bool { return feed.ID == arg.ID }, func(feed *database.Feed) {
+		feed.NextFetchAt = sql.NullTime{Time: arg.NextFetchAt, Valid: true}
+		feed.FetchIntervalSeconds = sql.NullInt64{Int64: arg.FetchIntervalSeconds, Valid: true}
+		feed.FetchIntervalSource = sql.NullString{String: arg.FetchIntervalSource, Valid: true}
+	})
+	return nil
+}
+
+// GetNextFeedToFetch returns the feed due the longest, feeds never fetched
+// first.
+func (s *Store) GetNextFeedToFetch(ctx context.Context, now time.Time) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	due := filter(s.feeds, func(feed database.Feed) bool {
+		return !feed.NextFetchAt.Valid || !feed.NextFetchAt.Time.After(now)
+	})
+	if len(due) == 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	return slices.MinFunc(due, func(a, b database.Feed) int {
+		order := compareNullsFirst(a.NextFetchAt, b.NextFetchAt)
+		if order != 0 {
+			return order
+		}
+		return compareNullsFirst(a.LastFetchedAt, b.LastFetchedAt)
+	}), nil
+}
+
+func compareNullsFirst(a, b sql.NullTime) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return -1
+	case !b.Valid:
+		return 1
+	}
+	return a.Time.Compare(b.Time)
+}
+
+func (s *Store) CountOverdueFeeds(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return count(s.feeds, func(feed database.Feed) bool {
+		due := feed.NextFetchAt
+		if !due.Valid {
+			due = feed.LastFetchedAt
+		}
+		return !due.Valid || due.Time.Before(before)
+	}), nil
+}
+
+func (s *Store) UpdateFeed(ctx context.Context, arg database.UpdateFeedParams) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := find(s.feeds, func(feed database.Feed) bool { return feed.Url == arg.Url && feed.ID != arg.ID })
+	if err == nil {
+		return database.Feed{}, errUnique("feeds_url_key")
+	}
+	update(s.feeds, func(feed database.Feed) bool { return feed.ID == arg.ID }, func(feed *database.Feed) {
+		feed.Name = arg.Name
+		feed.Url = arg.Url
+		feed.RetentionMaxAgeSeconds = arg.RetentionMaxAgeSeconds
+		feed.RetentionMaxPosts = arg.RetentionMaxPosts
+		feed.RefreshIntervalSeconds = arg.RefreshIntervalSeconds
+		feed.NextFetchAt = arg.NextFetchAt
+		feed.UpdatedAt = arg.UpdatedAt
+	})
+	return find(s.feeds, func(feed database.Feed) bool { return feed.ID == arg.ID })
+}
+
+func (s *Store) GetAllFeeds(ctx context.Context) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	feeds := slices.Clone(s.feeds)
+	slices.SortStableFunc(feeds, func(a, b database.Feed) int { return strings.Compare(a.Name, b.Name) })
+	return feeds, nil
+}
+
+func (s *Store) SetFeedOwner(ctx context.Context, arg database.SetFeedOwnerParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.feeds, func(feed database.Feed) bool { return feed.ID == arg.ID }, func(feed *database.Feed) {
+		feed.UserID = arg.UserID
+		feed.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (s *Store) GetFeedUsage(ctx context.Context, feedID uuid.UUID) (database.GetFeedUsageRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return database.GetFeedUsageRow{
+		Followers: count(s.feedFollows, func(follow database.FeedFollow) bool { return follow.FeedID == feedID }),
+		Posts:     count(s.posts, func(post database.Post) bool { return post.FeedID == feedID }),
+	}, nil
+}
+
+func (s *Store) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.feeds, func(feed database.Feed) bool { return feed.ID == id })
+	s.cascade()
+	return nil
+}
+
+func (s *Store) ResetFeedFetchState(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.feeds, func(database.Feed) bool { return true }, func(feed *database.Feed) {
+		feed.LastFetchedAt = sql.NullTime{}
+		feed.NextFetchAt = sql.NullTime{}
+	})
+	return nil
+}
+
+func (s *Store) ResetFeeds(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feeds = nil
+	s.cascade()
+	return nil
+}
+
+func (s *Store) GetFeeds(ctx context.Context) ([]database.GetFeedsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetFeedsRow{}
+	for _, feed := range s.feeds {
+		owner, _ := find(s.users, func(user database.User) bool {
+			return feed.UserID.Valid && user.ID == feed.UserID.UUID
+		})
+		rows = append(rows, database.GetFeedsRow{
+			Name:   feed.Name,
+			Url:    feed.Url,
+			Name_2: owner.Name,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) GetDataCounts(ctx context.Context) (database.GetDataCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return database.GetDataCountsRow{
+		Users:        int64(len(s.users)),
+		Feeds:        int64(len(s.feeds)),
+		FeedFollows:  int64(len(s.feedFollows)),
+		Posts:        int64(len(s.posts)),
+		FetchedFeeds: count(s.feeds, func(feed database.Feed) bool { return feed.LastFetchedAt.Valid }),
+	}, nil
+}
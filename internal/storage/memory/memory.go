@@ -0,0 +1,257 @@
+// Package memory keeps gator's data in memory. It implements the same
+// database.Querier the PostgreSQL and SQLite backends do, so commands and
+// servers can be exercised without a database server.
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// Store holds one table per slice, in insertion order, and follows the
+// schema's unique constraints and ON DELETE rules.
+type Store struct {
+	mu sync.Mutex
+
+	users             []database.User
+	feeds             []database.Feed
+	feedFetches       []database.FeedFetch
+	feedFollows       []database.FeedFollow
+	feedFollowTags    []database.FeedFollowTag
+	posts             []database.Post
+	prunedPosts       []database.PrunedPost
+	postStates        []database.PostState
+	postTags          []database.PostTag
+	rules             []database.Rule
+	webhooks          []database.Webhook
+	webhookDeliveries []database.WebhookDelivery
+	alerts            []database.Alert
+	alertEvents       []database.AlertEvent
+
+	lastFeedAPIID int64
+	lastItemID    int64
+}
+
+var _ database.Querier = (*Store)(nil)
+
+func New() *Store {
+	return &Store{}
+}
+
+func errUnique(constraint string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q", constraint)
+}
+
+func errForeignKey(constraint string) error {
+	return fmt.Errorf("insert or update violates foreign key constraint %q", constraint)
+}
+
+// find returns a copy of the first row matching, or sql.ErrNoRows like a
+// :one query that matches nothing.
+func find[T any](rows []T, match func(T) bool) (T, error) {
+	for _, row := range rows {
+		if match(row) {
+			return row, nil
+		}
+	}
+	var zero T
+	return zero, sql.ErrNoRows
+}
+
+func filter[T any](rows []T, match func(T) bool) []T {
+	matched := []T{}
+	for _, row := range rows {
+		if match(row) {
+			matched = append(matched, row)
+		}
+	}
+	return matched
+}
+
+func count[T any](rows []T, match func(T) bool) int64 {
+	var n int64
+	for _, row := range rows {
+		if match(row) {
+			n++
+		}
+	}
+	return n
+}
+
+// update applies change to every row matching and returns how many it
+// changed, like an :execrows query.
+func update[T any](rows []T, match func(T) bool, change func(*T)) int64 {
+	var n int64
+	for i := range rows {
+		if match(rows[i]) {
+			change(&rows[i])
+			n++
+		}
+	}
+	return n
+}
+
+// remove deletes the rows matching and returns how many it deleted.
+func remove[T any](rows *[]T, match func(T) bool) int64 {
+	before := len(*rows)
+	*rows = slices.DeleteFunc(*rows, match)
+	return int64(before - len(*rows))
+}
+
+// cascade applies the schema's ON DELETE rules after rows were deleted:
+// rows referencing a deleted row go with it, and feeds of a deleted user
+// lose their owner.
+func (s *Store) cascade() {
+	users := map[uuid.UUID]bool{}
+	for _, user := range s.users {
+		users[user.ID] = true
+	}
+	for i := range s.feeds {
+		if s.feeds[i].UserID.Valid && !users[s.feeds[i].UserID.UUID] {
+			s.feeds[i].UserID = uuid.NullUUID{}
+		}
+	}
+	feeds := map[uuid.UUID]bool{}
+	for _, feed := range s.feeds {
+		feeds[feed.ID] = true
+	}
+	optionalFeed := func(feedID uuid.NullUUID) bool {
+		return !feedID.Valid || feeds[feedID.UUID]
+	}
+
+	remove(&s.feedFetches, func(fetch database.FeedFetch) bool {
+		return !feeds[fetch.FeedID]
+	})
+	remove(&s.feedFollows, func(follow database.FeedFollow) bool {
+		return !users[follow.UserID] || !feeds[follow.FeedID]
+	})
+	follows := map[uuid.UUID]bool{}
+	for _, follow := range s.feedFollows {
+		follows[follow.ID] = true
+	}
+	remove(&s.feedFollowTags, func(tag database.FeedFollowTag) bool {
+		return !follows[tag.FeedFollowID]
+	})
+
+	remove(&s.posts, func(post database.Post) bool {
+		return !feeds[post.FeedID]
+	})
+	remove(&s.prunedPosts, func(pruned database.PrunedPost) bool {
+		return !feeds[pruned.FeedID]
+	})
+	posts := map[uuid.UUID]bool{}
+	for _, post := range s.posts {
+		posts[post.ID] = true
+	}
+	remove(&s.postStates, func(state database.PostState) bool {
+		return !users[state.UserID] || !posts[state.PostID]
+	})
+	remove(&s.postTags, func(tag database.PostTag) bool {
+		return !users[tag.UserID] || !posts[tag.PostID]
+	})
+
+	remove(&s.rules, func(rule database.Rule) bool {
+		return !users[rule.UserID] || !optionalFeed(rule.FeedID)
+	})
+	remove(&s.webhooks, func(webhook database.Webhook) bool {
+		return !users[webhook.UserID] || !optionalFeed(webhook.FeedID)
+	})
+	webhooks := map[uuid.UUID]bool{}
+	for _, webhook := range s.webhooks {
+		webhooks[webhook.ID] = true
+	}
+	remove(&s.webhookDeliveries, func(delivery database.WebhookDelivery) bool {
+		return !webhooks[delivery.WebhookID] || !posts[delivery.PostID]
+	})
+	remove(&s.alerts, func(alert database.Alert) bool {
+		return !users[alert.UserID]
+	})
+	alerts := map[uuid.UUID]bool{}
+	for _, alert := range s.alerts {
+		alerts[alert.ID] = true
+	}
+	remove(&s.alertEvents, func(event database.AlertEvent) bool {
+		return !alerts[event.AlertID] || !posts[event.PostID]
+	})
+}
+
+// followed returns the user's follow of the feed, if any.
+func (s *Store) followed(userID, feedID uuid.UUID) (database.FeedFollow, bool) {
+	follow, err := find(s.feedFollows, func(follow database.FeedFollow) bool {
+		return follow.UserID == userID && follow.FeedID == feedID
+	})
+	return follow, err == nil
+}
+
+func (s *Store) followTagged(followID uuid.UUID, tag sql.NullString) bool {
+	if !tag.Valid {
+		return true
+	}
+	_, err := find(s.feedFollowTags, func(followTag database.FeedFollowTag) bool {
+		return followTag.FeedFollowID == followID && followTag.Tag == tag.String
+	})
+	return err == nil
+}
+
+func (s *Store) feed(feedID uuid.UUID) (database.Feed, bool) {
+	feed, err := find(s.feeds, func(feed database.Feed) bool {
+		return feed.ID == feedID
+	})
+	return feed, err == nil
+}
+
+func (s *Store) user(userID uuid.UUID) (database.User, bool) {
+	user, err := find(s.users, func(user database.User) bool {
+		return user.ID == userID
+	})
+	return user, err == nil
+}
+
+func (s *Store) post(postID uuid.UUID) (database.Post, bool) {
+	post, err := find(s.posts, func(post database.Post) bool {
+		return post.ID == postID
+	})
+	return post, err == nil
+}
+
+// postState returns the user's state for the post, all false when the
+// user never changed it.
+func (s *Store) postState(userID, postID uuid.UUID) database.PostState {
+	state, _ := find(s.postStates, func(state database.PostState) bool {
+		return state.UserID == userID && state.PostID == postID
+	})
+	return state
+}
+
+// setPostState inserts or updates the user's state for the post.
+func (s *Store) setPostState(userID, postID uuid.UUID, updatedAt time.Time, change func(*database.PostState)) {
+	changed := update(s.postStates, func(state database.PostState) bool {
+		return state.UserID == userID && state.PostID == postID
+	}, func(state *database.PostState) {
+		change(state)
+		state.UpdatedAt = updatedAt
+	})
+	if changed > 0 {
+		return
+	}
+	state := database.PostState{
+		UserID:    userID,
+		PostID:    postID,
+		UpdatedAt: updatedAt,
+	}
+	change(&state)
+	s.postStates = append(s.postStates, state)
+}
+
+func feedTitle(follow database.FeedFollow, feed database.Feed) string {
+	if follow.Title.Valid {
+		return follow.Title.String
+	}
+	return feed.Name
+}
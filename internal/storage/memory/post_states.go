@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) checkPostState(userID, postID uuid.UUID) error {
+	if _, ok := s.user(userID); !ok {
+		return errForeignKey("post_states_user_id_fkey")
+	}
+	if _, ok := s.post(postID); !ok {
+		return errForeignKey("post_states_post_id_fkey")
+	}
+	return nil
+}
+
+func (s *Store) SetPostRead(ctx context.Context, arg database.SetPostReadParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.checkPostState(arg.UserID, arg.PostID)
+	if err != nil {
+		return err
+	}
+	s.setPostState(arg.UserID, arg.PostID, arg.UpdatedAt, func(state *database.PostState) {
+		state.Read = arg.Read
+	})
+	return nil
+}
+
+func (s *Store) SetPostStarred(ctx context.Context, arg database.SetPostStarredParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.checkPostState(arg.UserID, arg.PostID)
+	if err != nil {
+		return err
+	}
+	s.setPostState(arg.UserID, arg.PostID, arg.UpdatedAt, func(state *database.PostState) {
+		state.Starred = arg.Starred
+	})
+	return nil
+}
+
+func (s *Store) SetPostMuted(ctx context.Context, arg database.SetPostMutedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.checkPostState(arg.UserID, arg.PostID)
+	if err != nil {
+		return err
+	}
+	s.setPostState(arg.UserID, arg.PostID, arg.UpdatedAt, func(state *database.PostState) {
+		state.Muted = arg.Muted
+	})
+	return nil
+}
+
+func (s *Store) MarkPostsReadForUser(ctx context.Context, arg database.MarkPostsReadForUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.userPosts(arg.UserID) {
+		if arg.FeedID.Valid && p.post.FeedID != arg.FeedID.UUID {
+			continue
+		}
+		if !s.followTagged(p.follow.ID, arg.Tag) || p.post.CreatedAt.After(arg.OlderThan) {
+			continue
+		}
+		s.setPostState(arg.UserID, p.post.ID, arg.UpdatedAt, func(state *database.PostState) {
+			state.Read = true
+		})
+	}
+	return nil
+}
+
+func (s *Store) DeletePostStatesForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.postStates, func(state database.PostState) bool { return state.UserID == userID })
+	return nil
+}
+
+func (s *Store) AddPostTag(ctx context.Context, arg database.AddPostTagParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.user(arg.UserID); !ok {
+		return errForeignKey("post_tags_user_id_fkey")
+	}
+	if _, ok := s.post(arg.PostID); !ok {
+		return errForeignKey("post_tags_post_id_fkey")
+	}
+	_, err := find(s.postTags, func(tag database.PostTag) bool {
+		return tag.UserID == arg.UserID && tag.PostID == arg.PostID && tag.Tag == arg.Tag
+	})
+	if err == nil {
+		return nil
+	}
+	s.postTags = append(s.postTags, database.PostTag{
+		UserID:    arg.UserID,
+		PostID:    arg.PostID,
+		Tag:       arg.Tag,
+		CreatedAt: arg.CreatedAt,
+	})
+	return nil
+}
+
+func (s *Store) GetPostTags(ctx context.Context, arg database.GetPostTagsParams) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tags := []string{}
+	for _, tag := range s.postTags {
+		if tag.UserID == arg.UserID && tag.PostID == arg.PostID {
+			tags = append(tags, tag.Tag)
+		}
+	}
+	slices.SortFunc(tags, strings.Compare)
+	return tags, nil
+}
+
+func (s *Store) DeletePostTagsForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.postTags, func(tag database.PostTag) bool { return tag.UserID == userID })
+	return nil
+}
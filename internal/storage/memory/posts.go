@@ -0,0 +1,395 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// userPost is a post as a user sees it: joined with the feed, the user's
+// follow of it and the user's state for the post.
+type userPost struct {
+	post   database.Post
+	feed   database.Feed
+	follow database.FeedFollow
+	state  database.PostState
+}
+
+func (p userPost) streamRow() database.GetStreamForUserRow {
+	return database.GetStreamForUserRow{
+		ID:          p.post.ID,
+		CreatedAt:   p.post.CreatedAt,
+		UpdatedAt:   p.post.UpdatedAt,
+		Title:       p.post.Title,
+		Url:         p.post.Url,
+		Description: p.post.Description,
+		PublishedAt: p.post.PublishedAt,
+		FeedID:      p.post.FeedID,
+		ItemID:      p.post.ItemID,
+		Author:      p.post.Author,
+		Categories:  p.post.Categories,
+		FeedName:    feedTitle(p.follow, p.feed),
+		FeedUrl:     p.feed.Url,
+		FeedApiID:   p.feed.ApiID,
+		Read:        p.state.Read,
+		Starred:     p.state.Starred,
+		Muted:       p.state.Muted,
+	}
+}
+
+// userPosts returns the posts of the feeds the user follows, muted ones
+// included, by item id.
+func (s *Store) userPosts(userID uuid.UUID) []userPost {
+	posts := []userPost{}
+	for _, post := range s.posts {
+		follow, ok := s.followed(userID, post.FeedID)
+		if !ok {
+			continue
+		}
+		feed, _ := s.feed(post.FeedID)
+		posts = append(posts, userPost{
+			post:   post,
+			feed:   feed,
+			follow: follow,
+			state:  s.postState(userID, post.ID),
+		})
+	}
+	return posts
+}
+
+// CreatePost stores the post, or returns sql.ErrNoRows when a post with
+// the same url is stored already or was pruned.
+func (s *Store) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feed(arg.FeedID); !ok {
+		return database.Post{}, errForeignKey("posts_feed_id_fkey")
+	}
+	_, err := find(s.posts, func(post database.Post) bool { return post.Url == arg.Url })
+	if err == nil {
+		return database.Post{}, sql.ErrNoRows
+	}
+	_, err = find(s.prunedPosts, func(pruned database.PrunedPost) bool { return pruned.Url == arg.Url })
+	if err == nil {
+		return database.Post{}, sql.ErrNoRows
+	}
+	s.lastItemID++
+	post := database.Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+		ItemID:      s.lastItemID,
+		Author:      arg.Author,
+		Categories:  arg.Categories,
+	}
+	s.posts = append(s.posts, post)
+	return post, nil
+}
+
+// NotifyNewPost does nothing: there is no one listening in memory.
+func (s *Store) NotifyNewPost(ctx context.Context, arg database.NotifyNewPostParams) error {
+	return nil
+}
+
+// GetPostsForUser returns the newest posts first, dating the posts
+// without a publication date by when they were stored.
+func (s *Store) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := filter(s.userPosts(arg.UserID), func(p userPost) bool {
+		return !p.state.Muted && s.followTagged(p.follow.ID, arg.Tag)
+	})
+	slices.SortStableFunc(posts, func(a, b userPost) int {
+		return postDate(b.post).Compare(postDate(a.post))
+	})
+
+	rows := []database.GetPostsForUserRow{}
+	for _, p := range posts {
+		if int32(len(rows)) >= arg.Limit {
+			break
+		}
+		rows = append(rows, database.GetPostsForUserRow{
+			ID:          p.post.ID,
+			CreatedAt:   p.post.CreatedAt,
+			UpdatedAt:   p.post.UpdatedAt,
+			Title:       p.post.Title,
+			Url:         p.post.Url,
+			Description: p.post.Description,
+			PublishedAt: p.post.PublishedAt,
+			FeedID:      p.post.FeedID,
+			ItemID:      p.post.ItemID,
+			Author:      p.post.Author,
+			Categories:  p.post.Categories,
+			FeedName:    feedTitle(p.follow, p.feed),
+		})
+	}
+	return rows, nil
+}
+
+// postDate is when a post was published, or stored if it has no date.
+func postDate(post database.Post) time.Time {
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time
+	}
+	return post.CreatedAt
+}
+
+func (s *Store) GetAllPostsForUser(ctx context.Context, userID uuid.UUID) ([]database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := []database.Post{}
+	for _, p := range s.userPosts(userID) {
+		posts = append(posts, p.post)
+	}
+	return posts, nil
+}
+
+func (s *Store) ResetPosts(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posts = nil
+	s.cascade()
+	return nil
+}
+
+func (s *Store) GetStreamForUser(ctx context.Context, arg database.GetStreamForUserParams) ([]database.GetStreamForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := filter(s.userPosts(arg.UserID), func(p userPost) bool {
+		switch {
+		case p.state.Muted:
+			return false
+		case arg.FeedID.Valid && p.post.FeedID != arg.FeedID.UUID:
+			return false
+		case !s.followTagged(p.follow.ID, arg.Tag):
+			return false
+		case arg.UnreadOnly && p.state.Read:
+			return false
+		case arg.ReadOnly && !p.state.Read:
+			return false
+		case arg.StarredOnly && !p.state.Starred:
+			return false
+		case arg.NewerThan.Valid && p.post.CreatedAt.Before(arg.NewerThan.Time):
+			return false
+		case arg.OlderThan.Valid && p.post.CreatedAt.After(arg.OlderThan.Time):
+			return false
+		case arg.AfterItemID.Valid && p.post.ItemID <= arg.AfterItemID.Int64:
+			return false
+		case arg.BeforeItemID.Valid && p.post.ItemID >= arg.BeforeItemID.Int64:
+			return false
+		}
+		return true
+	})
+	if !arg.OldestFirst {
+		slices.Reverse(posts)
+	}
+
+	rows := []database.GetStreamForUserRow{}
+	for i, p := range posts {
+		if int32(i) < arg.SkipItems {
+			continue
+		}
+		if int32(len(rows)) >= arg.MaxItems {
+			break
+		}
+		rows = append(rows, p.streamRow())
+	}
+	return rows, nil
+}
+
+func (s *Store) GetPostForUserByItemID(ctx context.Context, arg database.GetPostForUserByItemIDParams) (database.GetPostForUserByItemIDRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := find(s.userPosts(arg.UserID), func(p userPost) bool { return p.post.ItemID == arg.ItemID })
+	if err != nil {
+		return database.GetPostForUserByItemIDRow{}, err
+	}
+	return database.GetPostForUserByItemIDRow(p.streamRow()), nil
+}
+
+func (s *Store) GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetUnreadCountsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetUnreadCountsForUserRow{}
+	for _, p := range s.userPosts(userID) {
+		if p.state.Read || p.state.Muted {
+			continue
+		}
+		i := slices.IndexFunc(rows, func(row database.GetUnreadCountsForUserRow) bool { return row.FeedID == p.post.FeedID })
+		if i < 0 {
+			rows = append(rows, database.GetUnreadCountsForUserRow{
+				FeedID:  p.post.FeedID,
+				FeedUrl: p.feed.Url,
+			})
+			i = len(rows) - 1
+		}
+		rows[i].UnreadCount++
+		if p.post.CreatedAt.After(rows[i].NewestCreatedAt) {
+			rows[i].NewestCreatedAt = p.post.CreatedAt
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) GetPostItemIDsForUser(ctx context.Context, arg database.GetPostItemIDsForUserParams) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	itemIDs := []int64{}
+	for _, p := range s.userPosts(arg.UserID) {
+		if p.state.Muted || (arg.UnreadOnly && p.state.Read) || (arg.StarredOnly && !p.state.Starred) {
+			continue
+		}
+		itemIDs = append(itemIDs, p.post.ItemID)
+	}
+	return itemIDs, nil
+}
+
+func (s *Store) CountPostsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return count(s.userPosts(userID), func(p userPost) bool { return !p.state.Muted }), nil
+}
+
+func (s *Store) GetPostsAfterItemID(ctx context.Context, itemID int64) ([]database.GetPostsAfterItemIDRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetPostsAfterItemIDRow{}
+	for _, post := range s.posts {
+		if post.ItemID > itemID {
+			rows = append(rows, database.GetPostsAfterItemIDRow{
+				ItemID: post.ItemID,
+				FeedID: post.FeedID,
+			})
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) GetLatestItemID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var latest int64
+	for _, post := range s.posts {
+		latest = max(latest, post.ItemID)
+	}
+	return latest, nil
+}
+
+func (s *Store) GetRecentPublishTimes(ctx context.Context, arg database.GetRecentPublishTimesParams) ([]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	published := []time.Time{}
+	for _, post := range s.posts {
+		if post.FeedID == arg.FeedID && post.PublishedAt.Valid {
+			published = append(published, post.PublishedAt.Time)
+		}
+	}
+	slices.SortFunc(published, func(a, b time.Time) int { return b.Compare(a) })
+	return published[:min(len(published), int(arg.Limit))], nil
+}
+
+// prunable tells the posts PrunePosts deletes: the feed's posts stored
+// before OlderThan or beyond its KeepNewest latest, unless starred.
+func (s *Store) prunable(arg database.PrunePostsParams) func(database.Post) bool {
+	var newest []int64
+	for _, post := range s.posts {
+		if post.FeedID == arg.FeedID {
+			newest = append(newest, post.ItemID)
+		}
+	}
+	slices.Sort(newest)
+	slices.Reverse(newest)
+
+	return func(post database.Post) bool {
+		if post.FeedID != arg.FeedID {
+			return false
+		}
+		starred := slices.ContainsFunc(s.postStates, func(state database.PostState) bool {
+			return state.PostID == post.ID && state.Starred
+		})
+		if starred {
+			return false
+		}
+		if arg.OlderThan.Valid && post.CreatedAt.Before(arg.OlderThan.Time) {
+			return true
+		}
+		return arg.KeepNewest > 0 && int(arg.KeepNewest) < len(newest) && post.ItemID <= newest[arg.KeepNewest]
+	}
+}
+
+func (s *Store) MarkPostsPruned(ctx context.Context, arg database.MarkPostsPrunedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prunable := s.prunable(database.PrunePostsParams{
+		FeedID:     arg.FeedID,
+		OlderThan:  arg.OlderThan,
+		KeepNewest: arg.KeepNewest,
+	})
+	for _, post := range filter(s.posts, prunable) {
+		_, err := find(s.prunedPosts, func(pruned database.PrunedPost) bool { return pruned.Url == post.Url })
+		if err == nil {
+			continue
+		}
+		s.prunedPosts = append(s.prunedPosts, database.PrunedPost{
+			Url:      post.Url,
+			FeedID:   post.FeedID,
+			PrunedAt: arg.PrunedAt,
+		})
+	}
+	return nil
+}
+
+func (s *Store) GetPrunedPostURLsForFeed(ctx context.Context, feedID uuid.UUID) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urls := []string{}
+	for _, pruned := range s.prunedPosts {
+		if pruned.FeedID == feedID {
+			urls = append(urls, pruned.Url)
+		}
+	}
+	return urls, nil
+}
+
+func (s *Store) DeletePrunedPost(ctx context.Context, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.prunedPosts, func(pruned database.PrunedPost) bool { return pruned.Url == url })
+	return nil
+}
+
+func (s *Store) PrunePosts(ctx context.Context, arg database.PrunePostsParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pruned := remove(&s.posts, s.prunable(arg))
+	s.cascade()
+	return pruned, nil
+}
+
+func (s *Store) GetPostDataCounts(ctx context.Context) (database.GetPostDataCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return database.GetPostDataCountsRow{
+		Posts:             int64(len(s.posts)),
+		PostStates:        int64(len(s.postStates)),
+		PostTags:          int64(len(s.postTags)),
+		WebhookDeliveries: int64(len(s.webhookDeliveries)),
+		AlertEvents:       int64(len(s.alertEvents)),
+	}, nil
+}
+
+func (s *Store) ResetPrunedPosts(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prunedPosts = nil
+	return nil
+}
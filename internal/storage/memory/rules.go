@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateRule(ctx context.Context, arg database.CreateRuleParams) (database.Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.user(arg.UserID); !ok {
+		return database.Rule{}, errForeignKey("rules_user_id_fkey")
+	}
+	rule := database.Rule(arg)
+	s.rules = append(s.rules, rule)
+	return rule, nil
+}
+
+func (s *Store) GetRulesForUser(ctx context.Context, userID uuid.UUID) ([]database.GetRulesForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetRulesForUserRow{}
+	for _, rule := range s.rules {
+		if rule.UserID != userID {
+			continue
+		}
+		row := database.GetRulesForUserRow{
+			ID:                 rule.ID,
+			CreatedAt:          rule.CreatedAt,
+			UpdatedAt:          rule.UpdatedAt,
+			UserID:             rule.UserID,
+			FeedID:             rule.FeedID,
+			TitlePattern:       rule.TitlePattern,
+			DescriptionPattern: rule.DescriptionPattern,
+			Author:             rule.Author,
+			Category:           rule.Category,
+			Action:             rule.Action,
+			Tag:                rule.Tag,
+		}
+		if feed, ok := s.feed(rule.FeedID.UUID); rule.FeedID.Valid && ok {
+			row.FeedUrl = sql.NullString{String: feed.Url, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// GetRulesForFeed returns the rules of every follower of the feed that
+// apply to all their feeds or to this one.
+func (s *Store) GetRulesForFeed(ctx context.Context, feedID uuid.UUID) ([]database.Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.rules, func(rule database.Rule) bool {
+		_, following := s.followed(rule.UserID, feedID)
+		return following && (!rule.FeedID.Valid || rule.FeedID.UUID == feedID)
+	}), nil
+}
+
+func (s *Store) DeleteRule(ctx context.Context, arg database.DeleteRuleParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return remove(&s.rules, func(rule database.Rule) bool {
+		return rule.ID == arg.ID && rule.UserID == arg.UserID
+	}), nil
+}
+
+func (s *Store) DeleteRulesForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.rules, func(rule database.Rule) bool { return rule.UserID == userID })
+	return nil
+}
@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := find(s.users, func(user database.User) bool { return user.Name == arg.Name })
+	if err == nil {
+		return database.User{}, errUnique("users_name_key")
+	}
+	user := database.User{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		IsAdmin:   arg.IsAdmin,
+	}
+	s.users = append(s.users, user)
+	return user, nil
+}
+
+func (s *Store) GetUser(ctx context.Context, name string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return find(s.users, func(user database.User) bool { return user.Name == name })
+}
+
+func (s *Store) GetUsers(ctx context.Context) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.users), nil
+}
+
+func (s *Store) CountUsers(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.users)), nil
+}
+
+func (s *Store) CountAdmins(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return count(s.users, func(user database.User) bool { return user.IsAdmin }), nil
+}
+
+func (s *Store) SetUserAdmin(ctx context.Context, arg database.SetUserAdminParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.users, func(user database.User) bool { return user.ID == arg.ID }, func(user *database.User) {
+		user.IsAdmin = arg.IsAdmin
+		user.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (s *Store) RenameUser(ctx context.Context, arg database.RenameUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := find(s.users, func(user database.User) bool { return user.Name == arg.Name && user.ID != arg.ID })
+	if err == nil {
+		return errUnique("users_name_key")
+	}
+	update(s.users, func(user database.User) bool { return user.ID == arg.ID }, func(user *database.User) {
+		user.Name = arg.Name
+		user.ApiPasswordHash = sql.NullString{}
+		user.FeverApiKey = sql.NullString{}
+		user.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (s *Store) GetUserUsage(ctx context.Context, userID uuid.UUID) (database.GetUserUsageRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return database.GetUserUsageRow{
+		Follows:    count(s.feedFollows, func(follow database.FeedFollow) bool { return follow.UserID == userID }),
+		OwnedFeeds: count(s.feeds, func(feed database.Feed) bool { return feed.UserID.Valid && feed.UserID.UUID == userID }),
+		PostStates: count(s.postStates, func(state database.PostState) bool { return state.UserID == userID }),
+		PostTags:   count(s.postTags, func(tag database.PostTag) bool { return tag.UserID == userID }),
+		Webhooks:   count(s.webhooks, func(webhook database.Webhook) bool { return webhook.UserID == userID }),
+		Rules:      count(s.rules, func(rule database.Rule) bool { return rule.UserID == userID }),
+		Alerts:     count(s.alerts, func(alert database.Alert) bool { return alert.UserID == userID }),
+	}, nil
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.users, func(user database.User) bool { return user.ID == id })
+	s.cascade()
+	return nil
+}
+
+func (s *Store) ResetUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = nil
+	s.cascade()
+	return nil
+}
+
+func (s *Store) SetUserAPIPassword(ctx context.Context, arg database.SetUserAPIPasswordParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if arg.FeverApiKey.Valid {
+		_, err := find(s.users, func(user database.User) bool { return user.FeverApiKey == arg.FeverApiKey && user.ID != arg.ID })
+		if err == nil {
+			return errUnique("users_fever_api_key_key")
+		}
+	}
+	update(s.users, func(user database.User) bool { return user.ID == arg.ID }, func(user *database.User) {
+		user.ApiPasswordHash = arg.ApiPasswordHash
+		user.FeverApiKey = arg.FeverApiKey
+		user.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (s *Store) GetUserByFeverAPIKey(ctx context.Context, feverApiKey sql.NullString) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return find(s.users, func(user database.User) bool {
+		return feverApiKey.Valid && user.FeverApiKey == feverApiKey
+	})
+}
+
+func (s *Store) SetUserEmail(ctx context.Context, arg database.SetUserEmailParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.users, func(user database.User) bool { return user.ID == arg.ID }, func(user *database.User) {
+		user.Email = arg.Email
+		user.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (s *Store) GetDigestRecipients(ctx context.Context) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recipients := filter(s.users, func(user database.User) bool { return user.Email.Valid })
+	slices.SortStableFunc(recipients, func(a, b database.User) int { return strings.Compare(a.Name, b.Name) })
+	return recipients, nil
+}
+
+func (s *Store) MarkDigestSent(ctx context.Context, arg database.MarkDigestSentParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.users, func(user database.User) bool { return user.ID == arg.ID }, func(user *database.User) {
+		user.LastDigestAt = arg.LastDigestAt
+		user.LastDigestItemID = arg.LastDigestItemID
+	})
+	return nil
+}
@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateWebhook(ctx context.Context, arg database.CreateWebhookParams) (database.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.user(arg.UserID); !ok {
+		return database.Webhook{}, errForeignKey("webhooks_user_id_fkey")
+	}
+	webhook := database.Webhook(arg)
+	s.webhooks = append(s.webhooks, webhook)
+	return webhook, nil
+}
+
+func (s *Store) GetWebhooksForUser(ctx context.Context, userID uuid.UUID) ([]database.GetWebhooksForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetWebhooksForUserRow{}
+	for _, webhook := range s.webhooks {
+		if webhook.UserID != userID {
+			continue
+		}
+		row := database.GetWebhooksForUserRow{
+			ID:        webhook.ID,
+			CreatedAt: webhook.CreatedAt,
+			UpdatedAt: webhook.UpdatedAt,
+			UserID:    webhook.UserID,
+			Url:       webhook.Url,
+			FeedID:    webhook.FeedID,
+			Secret:    webhook.Secret,
+		}
+		if feed, ok := s.feed(webhook.FeedID.UUID); webhook.FeedID.Valid && ok {
+			row.FeedUrl = sql.NullString{String: feed.Url, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, arg database.DeleteWebhookParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := remove(&s.webhooks, func(webhook database.Webhook) bool {
+		return webhook.ID == arg.ID && webhook.UserID == arg.UserID
+	})
+	s.cascade()
+	return deleted, nil
+}
+
+// GetWebhooksForFeed returns the webhooks of every follower of the feed
+// that fire for all their feeds or for this one.
+func (s *Store) GetWebhooksForFeed(ctx context.Context, feedID uuid.UUID) ([]database.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.webhooks, func(webhook database.Webhook) bool {
+		_, following := s.followed(webhook.UserID, feedID)
+		return following && (!webhook.FeedID.Valid || webhook.FeedID.UUID == feedID)
+	}), nil
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := find(s.webhooks, func(webhook database.Webhook) bool { return webhook.ID == arg.WebhookID })
+	if err != nil {
+		return errForeignKey("webhook_deliveries_webhook_id_fkey")
+	}
+	if _, ok := s.post(arg.PostID); !ok {
+		return errForeignKey("webhook_deliveries_post_id_fkey")
+	}
+	s.webhookDeliveries = append(s.webhookDeliveries, database.WebhookDelivery{
+		ID:            arg.ID,
+		CreatedAt:     arg.CreatedAt,
+		UpdatedAt:     arg.UpdatedAt,
+		WebhookID:     arg.WebhookID,
+		PostID:        arg.PostID,
+		Status:        "pending",
+		NextAttemptAt: arg.CreatedAt,
+	})
+	return nil
+}
+
+func (s *Store) GetDueWebhookDeliveries(ctx context.Context, arg database.GetDueWebhookDeliveriesParams) ([]database.GetDueWebhookDeliveriesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	due := filter(s.webhookDeliveries, func(delivery database.WebhookDelivery) bool {
+		return delivery.Status == "pending" && !delivery.NextAttemptAt.After(arg.Now)
+	})
+	slices.SortStableFunc(due, func(a, b database.WebhookDelivery) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+
+	rows := []database.GetDueWebhookDeliveriesRow{}
+	for _, delivery := range due[:min(len(due), int(arg.MaxDeliveries))] {
+		webhook, _ := find(s.webhooks, func(webhook database.Webhook) bool { return webhook.ID == delivery.WebhookID })
+		post, _ := s.post(delivery.PostID)
+		feed, _ := s.feed(post.FeedID)
+		rows = append(rows, database.GetDueWebhookDeliveriesRow{
+			ID:          delivery.ID,
+			Attempts:    delivery.Attempts,
+			WebhookUrl:  webhook.Url,
+			Secret:      webhook.Secret,
+			ItemID:      post.ItemID,
+			Title:       post.Title,
+			PostUrl:     post.Url,
+			Description: post.Description,
+			PublishedAt: post.PublishedAt,
+			FeedName:    feed.Name,
+			FeedUrl:     feed.Url,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) MarkWebhookDeliveryDelivered(ctx context.Context, arg database.MarkWebhookDeliveryDeliveredParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.webhookDeliveries, func(delivery database.WebhookDelivery) bool { return delivery.ID == arg.ID }, func(delivery *database.WebhookDelivery) {
+		delivery.Status = "delivered"
+		delivery.Attempts++
+		delivery.LastStatusCode = arg.LastStatusCode
+		delivery.LastError = sql.NullString{}
+		delivery.UpdatedAt = arg.DeliveredAt
+		delivery.DeliveredAt = sql.NullTime{Time: arg.DeliveredAt, Valid: true}
+	})
+	return nil
+}
+
+func (s *Store) MarkWebhookDeliveryFailed(ctx context.Context, arg database.MarkWebhookDeliveryFailedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.webhookDeliveries, func(delivery database.WebhookDelivery) bool { return delivery.ID == arg.ID }, func(delivery *database.WebhookDelivery) {
+		delivery.Status = arg.Status
+		delivery.Attempts++
+		delivery.LastStatusCode = arg.LastStatusCode
+		delivery.LastError = arg.LastError
+		delivery.NextAttemptAt = arg.NextAttemptAt
+		delivery.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+// GetWebhookDeliveriesForUser returns the user's latest deliveries first.
+func (s *Store) GetWebhookDeliveriesForUser(ctx context.Context, arg database.GetWebhookDeliveriesForUserParams) ([]database.GetWebhookDeliveriesForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := []database.GetWebhookDeliveriesForUserRow{}
+	for _, delivery := range s.webhookDeliveries {
+		webhook, err := find(s.webhooks, func(webhook database.Webhook) bool { return webhook.ID == delivery.WebhookID })
+		if err != nil || webhook.UserID != arg.UserID {
+			continue
+		}
+		post, _ := s.post(delivery.PostID)
+		rows = append(rows, database.GetWebhookDeliveriesForUserRow{
+			ID:             delivery.ID,
+			CreatedAt:      delivery.CreatedAt,
+			UpdatedAt:      delivery.UpdatedAt,
+			WebhookID:      delivery.WebhookID,
+			PostID:         delivery.PostID,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      delivery.LastError,
+			DeliveredAt:    delivery.DeliveredAt,
+			WebhookUrl:     webhook.Url,
+			PostTitle:      post.Title,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetWebhookDeliveriesForUserRow) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return rows[:min(len(rows), int(arg.Limit))], nil
+}
+
+func (s *Store) DeleteWebhooksForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove(&s.webhooks, func(webhook database.Webhook) bool { return webhook.UserID == userID })
+	s.cascade()
+	return nil
+}
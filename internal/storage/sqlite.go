@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"modernc.org/sqlite"
+)
+
+var (
+	placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+	castPattern        = regexp.MustCompile(`::[a-z_]+`)
+
+	rewrittenQueries sync.Map
+)
+
+func init() {
+	// The aggregator announces new posts with pg_notify. SQLite has no
+	// LISTEN/NOTIFY, so the call is a no-op and serve polls instead.
+	sqlite.MustRegisterScalarFunction("pg_notify", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return nil, nil
+	})
+}
+
+// openSQLite opens the database file with foreign keys enforced, times
+// stored in UTC so they compare as text, and expressions over timestamp
+// columns (MAX(created_at)...) read back as time.Time.
+func openSQLite(path string) (*sql.DB, error) {
+	dsn, query, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing the SQLite options: %v", err)
+	}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Set("_time_format", "sqlite")
+	params.Set("_timezone", "UTC")
+	params.Set("_texttotime", "1")
+
+	conn, err := sql.Open("sqlite", "file:"+dsn+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("Error opening the SQLite database: %v", err)
+	}
+	// SQLite allows a single writer; sharing one connection queues writes
+	// instead of failing them with SQLITE_BUSY.
+	conn.SetMaxOpenConns(1)
+	return conn, nil
+}
+
+// sqliteDBTX runs the PostgreSQL queries generated by sqlc on SQLite. The
+// queries are kept to the SQL both databases understand, except for $N
+// placeholders and ::type casts, which are rewritten here.
+type sqliteDBTX struct {
+	db database.DBTX
+}
+
+func (s sqliteDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(ctx, rewriteForSQLite(query), args...)
+}
+
+func (s sqliteDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.db.PrepareContext(ctx, rewriteForSQLite(query))
+}
+
+func (s sqliteDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, rewriteForSQLite(query), args...)
+}
+
+func (s sqliteDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRowContext(ctx, rewriteForSQLite(query), args...)
+}
+
+func rewriteForSQLite(query string) string {
+	if rewritten, ok := rewrittenQueries.Load(query); ok {
+		return rewritten.(string)
+	}
+	rewritten := placeholderPattern.ReplaceAllString(query, "?$1")
+	rewritten = castPattern.ReplaceAllString(rewritten, "")
+	rewrittenQueries.Store(query, rewritten)
+	return rewritten
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Mr-Rafael/gator/internal/database"
+	"github.com/Mr-Rafael/gator/internal/metrics"
+	_ "github.com/lib/pq"
+)
+
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// DB is an open database. Queries is what commands and servers run; the
+// connection underneath is exposed for transactions and migrations.
+type DB struct {
+	Dialect string
+	Conn    *sql.DB
+	Queries database.Querier
+}
+
+// Open connects to the database named by db_url. URLs starting with
+// "sqlite:" open an SQLite file ("sqlite:gator.db", "sqlite:///abs/gator.db"),
+// anything else is handed to the PostgreSQL driver.
+func Open(dbURL string) (*DB, error) {
+	path, ok := strings.CutPrefix(dbURL, "sqlite:")
+	if !ok {
+		conn, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			return nil, fmt.Errorf("Error connecting to the database: %v", err)
+		}
+		return &DB{
+			Dialect: Postgres,
+			Conn:    conn,
+			Queries: newQueries(Postgres, conn),
+		}, nil
+	}
+
+	conn, err := openSQLite(strings.TrimPrefix(path, "//"))
+	if err != nil {
+		return nil, err
+	}
+	return &DB{
+		Dialect: SQLite,
+		Conn:    conn,
+		Queries: newQueries(SQLite, conn),
+	}, nil
+}
+
+// InTx runs fn with queries bound to a transaction, committing it when fn
+// succeeds and rolling it back otherwise.
+func (db *DB) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	tx, err := db.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Error starting the transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = fn(newQueries(db.Dialect, tx))
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("Error committing the transaction: %v", err)
+	}
+	return nil
+}
+
+func newQueries(dialect string, conn database.DBTX) database.Querier {
+	if dialect == SQLite {
+		conn = sqliteDBTX{conn}
+	}
+	return database.New(countingDBTX{conn})
+}
+
+// countingDBTX counts the queries that fail in the metrics. Rows a query
+// doesn't find aren't failures: sql.ErrNoRows only comes up on Scan.
+type countingDBTX struct {
+	db database.DBTX
+}
+
+func (c countingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := c.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		metrics.DBQueryErrors.Inc()
+	}
+	return result, err
+}
+
+func (c countingDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		metrics.DBQueryErrors.Inc()
+	}
+	return stmt, err
+}
+
+func (c countingDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		metrics.DBQueryErrors.Inc()
+	}
+	return rows, err
+}
+
+func (c countingDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := c.db.QueryRowContext(ctx, query, args...)
+	if row.Err() != nil {
+		metrics.DBQueryErrors.Inc()
+	}
+	return row
+}
@@ -0,0 +1,109 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is
+	// given up as failed.
+	MaxAttempts = 6
+
+	SignatureHeader = "X-Gator-Signature"
+	DeliveryHeader  = "X-Gator-Delivery"
+	EventHeader     = "X-Gator-Event"
+
+	// Timeout caps each attempt to deliver a payload.
+	Timeout = 15 * time.Second
+)
+
+// retryDelays is the wait after each failed attempt.
+var retryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+// PostEvent is the JSON body POSTed to webhooks for every new post.
+type PostEvent struct {
+	Event       string     `json:"event"`
+	DeliveryID  uuid.UUID  `json:"delivery_id"`
+	ItemID      int64      `json:"item_id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+	Feed        Feed       `json:"feed"`
+}
+
+type Feed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// NewSecret returns a random secret for signing a webhook's payloads.
+func NewSecret() string {
+	secret := make([]byte, 24)
+	rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of the body keyed with the webhook secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send POSTs a signed body to url with client. It returns the response
+// status code (0 if no response was received) and an error unless the
+// receiver answered with a 2xx status.
+func Send(ctx context.Context, client *http.Client, url, secret string, deliveryID uuid.UUID, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("Failed to generate the request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+	req.Header.Set(DeliveryHeader, deliveryID.String())
+	req.Header.Set(EventHeader, "post")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to reach the webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("Webhook answered with status: %v", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// RetryDelay returns how long to wait before retrying a delivery that has
+// failed the given number of times.
+func RetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		return retryDelays[0]
+	}
+	if attempts > len(retryDelays) {
+		return retryDelays[len(retryDelays)-1]
+	}
+	return retryDelays[attempts-1]
+}
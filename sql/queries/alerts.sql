@@ -0,0 +1,57 @@
+-- name: CreateAlert :one
+INSERT INTO alerts (id, created_at, updated_at, user_id, pattern, notifier, target)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetAlertsForUser :many
+SELECT *
+FROM alerts
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetAlertsForFeed :many
+SELECT alerts.*, users.name AS user_name
+FROM alerts
+INNER JOIN feed_follows
+    ON alerts.user_id = feed_follows.user_id
+INNER JOIN users
+    ON alerts.user_id = users.id
+WHERE feed_follows.feed_id = $1
+ORDER BY alerts.created_at;
+
+-- name: DeleteAlert :execrows
+DELETE FROM alerts
+WHERE id = $1 AND user_id = $2;
+
+-- name: CreateAlertEvent :exec
+INSERT INTO alert_events (id, created_at, alert_id, post_id, error)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: GetAlertEventsForUser :many
+SELECT alert_events.*, alerts.pattern, alerts.notifier, posts.title AS post_title, posts.url AS post_url
+FROM alert_events
+INNER JOIN alerts
+    ON alert_events.alert_id = alerts.id
+INNER JOIN posts
+    ON alert_events.post_id = posts.id
+WHERE alerts.user_id = $1
+ORDER BY alert_events.created_at DESC
+LIMIT $2;
+
+-- name: DeleteAlertsForUser :exec
+DELETE FROM alerts
+WHERE user_id = $1;
\ No newline at end of file
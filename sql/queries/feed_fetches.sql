@@ -0,0 +1,31 @@
+-- name: CreateFeedFetch :exec
+INSERT INTO feed_fetches (id, feed_id, started_at, finished_at, status_code, bytes, items, new_posts, error)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+);
+
+-- name: GetFeedFetches :many
+SELECT *
+FROM feed_fetches
+WHERE feed_id = $1
+ORDER BY started_at DESC
+LIMIT $2;
+
+-- name: TrimFeedFetches :exec
+-- Keeps the feed's latest fetches only.
+DELETE FROM feed_fetches
+WHERE feed_fetches.feed_id = sqlc.arg(feed_id)::uuid AND feed_fetches.id NOT IN (
+    SELECT kept.id
+    FROM feed_fetches AS kept
+    WHERE kept.feed_id = sqlc.arg(feed_id)::uuid
+    ORDER BY kept.started_at DESC
+    LIMIT sqlc.arg(keep)::integer
+);
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- name: AddFeedFollowTag :exec
+INSERT INTO feed_follow_tags (feed_follow_id, tag, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (feed_follow_id, tag) DO NOTHING;
+
+-- name: RemoveFeedFollowTag :execrows
+DELETE FROM feed_follow_tags
+WHERE feed_follow_id = $1 AND tag = $2;
+
+-- name: GetFeedFollowTagsForUser :many
+SELECT feed_follows.feed_id, feed_follow_tags.tag
+FROM feed_follow_tags
+INNER JOIN feed_follows
+    ON feed_follow_tags.feed_follow_id = feed_follows.id
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follow_tags.tag;
\ No newline at end of file
@@ -1,32 +1,46 @@
 -- name: CreateFeedFollow :one
-WITH inserted AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-    VALUES (
-        $1,
-        $2,
-        $3,
-        $4,
-        $5
-    )
-    RETURNING id, user_id, feed_id
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
 )
-SELECT *
-FROM inserted i
-INNER JOIN users
-    ON i.user_id = users.id
-INNER JOIN feeds
-    ON i.feed_id = feeds.id;
+RETURNING *;
 
 -- name: GetFeedFollowsForUser :many
-SELECT feed_follows.user_id, feed_follows.feed_id, name, url
+SELECT feed_follows.user_id, feed_follows.feed_id, COALESCE(feed_follows.title, feeds.name)::text AS name, url
 FROM feed_follows
 INNER JOIN feeds
     ON feed_follows.feed_id = feeds.id
 WHERE feed_follows.user_id = $1;
 
+-- name: GetFeedFollow :one
+SELECT *
+FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: GetFollowedFeeds :many
+SELECT feeds.*, COALESCE(feed_follows.title, feeds.name)::text AS title
+FROM feeds
+INNER JOIN feed_follows
+    ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+ORDER BY title;
+
+-- name: SetFeedFollowTitle :execrows
+UPDATE feed_follows
+SET title = $3, updated_at = $4
+WHERE user_id = $1 AND feed_id = $2;
+
 -- name: DeleteFollow :exec
 DELETE FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2;
 
 -- name: ResetFeedFollows :exec
-DELETE FROM feed_follows;
\ No newline at end of file
+DELETE FROM feed_follows;
+
+-- name: DeleteFeedFollowsForUser :exec
+DELETE FROM feed_follows
+WHERE user_id = $1;
\ No newline at end of file
@@ -15,15 +15,68 @@ SELECT *
 FROM feeds
 WHERE url = $1;
 
+-- name: GetFeedByAPIID :one
+SELECT *
+FROM feeds
+WHERE api_id = $1;
+
 -- name: MarkFeedFetched :exec
+-- Also schedules the next attempt, in case this one fails.
 UPDATE feeds
-SET last_fetched_at = $2, updated_at = $2
+SET last_fetched_at = $2, next_fetch_at = $3, updated_at = $2
 WHERE id = $1;
 
+-- name: ScheduleFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = sqlc.arg(next_fetch_at)::timestamp,
+    fetch_interval_seconds = sqlc.arg(fetch_interval_seconds)::bigint,
+    fetch_interval_source = sqlc.arg(fetch_interval_source)::text
+WHERE id = sqlc.arg(id);
+
 -- name: GetNextFeedToFetch :one
+-- Returns the feed due the longest, feeds never fetched first.
 SELECT *
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST;
+WHERE next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now)::timestamp
+ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+LIMIT 1;
+
+-- name: CountOverdueFeeds :one
+-- Counts the feeds due since before the given time, including the ones
+-- never fetched.
+SELECT COUNT(*)
+FROM feeds
+WHERE COALESCE(next_fetch_at, last_fetched_at) IS NULL
+    OR COALESCE(next_fetch_at, last_fetched_at) < sqlc.arg(before)::timestamp;
+
+-- name: UpdateFeed :one
+UPDATE feeds
+SET name = $2, url = $3, retention_max_age_seconds = $4, retention_max_posts = $5, refresh_interval_seconds = $6, next_fetch_at = $7, updated_at = $8
+WHERE id = $1
+RETURNING *;
+
+-- name: GetAllFeeds :many
+SELECT *
+FROM feeds
+ORDER BY name;
+
+-- name: SetFeedOwner :exec
+UPDATE feeds
+SET user_id = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: GetFeedUsage :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = sqlc.arg(feed_id)) AS followers,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = sqlc.arg(feed_id)) AS posts;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: ResetFeedFetchState :exec
+UPDATE feeds
+SET last_fetched_at = NULL, next_fetch_at = NULL;
 
 -- name: ResetFeeds :exec
 DELETE FROM feeds;
\ No newline at end of file
@@ -1,4 +1,12 @@
 -- name: GetFeeds :many
-SELECT feeds.name, feeds.url, users.name
+SELECT feeds.name, feeds.url, COALESCE(users.name, '')::text AS name
 FROM feeds
-INNER JOIN users ON feeds.user_id = users.id;
\ No newline at end of file
+LEFT JOIN users ON feeds.user_id = users.id;
+
+-- name: GetDataCounts :one
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM feeds) AS feeds,
+    (SELECT COUNT(*) FROM feed_follows) AS feed_follows,
+    (SELECT COUNT(*) FROM posts) AS posts,
+    (SELECT COUNT(*) FROM feeds WHERE last_fetched_at IS NOT NULL) AS fetched_feeds;
\ No newline at end of file
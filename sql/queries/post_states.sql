@@ -0,0 +1,53 @@
+-- name: SetPostRead :exec
+INSERT INTO post_states (user_id, post_id, read, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET read = EXCLUDED.read, updated_at = EXCLUDED.updated_at;
+
+-- name: SetPostStarred :exec
+INSERT INTO post_states (user_id, post_id, starred, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET starred = EXCLUDED.starred, updated_at = EXCLUDED.updated_at;
+
+-- name: SetPostMuted :exec
+INSERT INTO post_states (user_id, post_id, muted, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET muted = EXCLUDED.muted, updated_at = EXCLUDED.updated_at;
+
+-- name: MarkPostsReadForUser :exec
+INSERT INTO post_states (user_id, post_id, read, updated_at)
+SELECT feed_follows.user_id, posts.id, true, sqlc.arg(updated_at)
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+    AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM feed_follow_tags
+        WHERE feed_follow_tags.feed_follow_id = feed_follows.id AND feed_follow_tags.tag = sqlc.narg(tag)
+    ))
+    AND posts.created_at <= sqlc.arg(older_than)
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET read = true, updated_at = EXCLUDED.updated_at;
+
+-- name: DeletePostStatesForUser :exec
+DELETE FROM post_states
+WHERE user_id = $1;
\ No newline at end of file
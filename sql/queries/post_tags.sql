@@ -0,0 +1,19 @@
+-- name: AddPostTag :exec
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: GetPostTags :many
+SELECT tag
+FROM post_tags
+WHERE user_id = $1 AND post_id = $2
+ORDER BY tag;
+
+-- name: DeletePostTagsForUser :exec
+DELETE FROM post_tags
+WHERE user_id = $1;
\ No newline at end of file
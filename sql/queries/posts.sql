@@ -1,24 +1,208 @@
--- name: CreatePost :exec
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES(
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-);
+-- name: CreatePost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories)
+SELECT sqlc.arg(id)::uuid, sqlc.arg(created_at)::timestamp, sqlc.arg(updated_at)::timestamp, sqlc.arg(title)::text, sqlc.arg(url)::text,
+    sqlc.narg(description)::text, sqlc.narg(published_at)::timestamp, sqlc.arg(feed_id)::uuid, sqlc.arg(author)::text, sqlc.arg(categories)::text
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM pruned_posts
+    WHERE pruned_posts.url = sqlc.arg(url)
+)
+ON CONFLICT (url) DO NOTHING
+RETURNING *;
+
+-- name: NotifyNewPost :exec
+SELECT pg_notify(sqlc.arg(channel), sqlc.arg(payload));
 
 -- name: GetPostsForUser :many
+SELECT posts.*, COALESCE(feed_follows.title, feeds.name)::text AS feed_name
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+    AND COALESCE(post_states.muted, false) = false
+    AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM feed_follow_tags
+        WHERE feed_follow_tags.feed_follow_id = feed_follows.id AND feed_follow_tags.tag = sqlc.narg(tag)
+    ))
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetAllPostsForUser :many
 SELECT posts.*
 FROM posts
 INNER JOIN feed_follows
     ON posts.feed_id = feed_follows.feed_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2;
+ORDER BY posts.item_id;
 
 -- name: ResetPosts :exec
-DELETE FROM posts;
\ No newline at end of file
+DELETE FROM posts;
+
+-- name: GetStreamForUser :many
+SELECT posts.*, COALESCE(feed_follows.title, feeds.name)::text AS feed_name, feeds.url AS feed_url, feeds.api_id AS feed_api_id,
+    COALESCE(post_states.read, false)::boolean AS read,
+    COALESCE(post_states.starred, false)::boolean AS starred,
+    COALESCE(post_states.muted, false)::boolean AS muted
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+    AND COALESCE(post_states.muted, false) = false
+    AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+    AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM feed_follow_tags
+        WHERE feed_follow_tags.feed_follow_id = feed_follows.id AND feed_follow_tags.tag = sqlc.narg(tag)
+    ))
+    AND (NOT sqlc.arg(unread_only)::boolean OR COALESCE(post_states.read, false) = false)
+    AND (NOT sqlc.arg(read_only)::boolean OR COALESCE(post_states.read, false) = true)
+    AND (NOT sqlc.arg(starred_only)::boolean OR COALESCE(post_states.starred, false) = true)
+    AND (sqlc.narg(newer_than)::timestamp IS NULL OR posts.created_at >= sqlc.narg(newer_than))
+    AND (sqlc.narg(older_than)::timestamp IS NULL OR posts.created_at <= sqlc.narg(older_than))
+    AND (sqlc.narg(after_item_id)::bigint IS NULL OR posts.item_id > sqlc.narg(after_item_id))
+    AND (sqlc.narg(before_item_id)::bigint IS NULL OR posts.item_id < sqlc.narg(before_item_id))
+ORDER BY
+    CASE WHEN sqlc.arg(oldest_first)::boolean THEN posts.item_id END ASC,
+    posts.item_id DESC
+LIMIT sqlc.arg(max_items) OFFSET sqlc.arg(skip_items);
+
+-- name: GetPostForUserByItemID :one
+SELECT posts.*, COALESCE(feed_follows.title, feeds.name)::text AS feed_name, feeds.url AS feed_url, feeds.api_id AS feed_api_id,
+    COALESCE(post_states.read, false)::boolean AS read,
+    COALESCE(post_states.starred, false)::boolean AS starred,
+    COALESCE(post_states.muted, false)::boolean AS muted
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.item_id = $2;
+
+-- name: GetUnreadCountsForUser :many
+SELECT posts.feed_id, feeds.url AS feed_url, COUNT(*) AS unread_count,
+    MAX(posts.created_at)::timestamp AS newest_created_at
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+    AND COALESCE(post_states.read, false) = false
+    AND COALESCE(post_states.muted, false) = false
+GROUP BY posts.feed_id, feeds.url;
+
+-- name: GetPostItemIDsForUser :many
+SELECT posts.item_id
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+    AND COALESCE(post_states.muted, false) = false
+    AND (NOT sqlc.arg(unread_only)::boolean OR COALESCE(post_states.read, false) = false)
+    AND (NOT sqlc.arg(starred_only)::boolean OR COALESCE(post_states.starred, false) = true)
+ORDER BY posts.item_id;
+
+-- name: CountPostsForUser :one
+SELECT COUNT(*)
+FROM posts
+INNER JOIN feed_follows
+    ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+    ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND COALESCE(post_states.muted, false) = false;
+
+-- name: GetPostsAfterItemID :many
+SELECT item_id, feed_id
+FROM posts
+WHERE item_id > $1
+ORDER BY item_id;
+
+-- name: GetLatestItemID :one
+SELECT COALESCE(MAX(item_id), 0)::bigint AS item_id
+FROM posts;
+
+-- name: GetRecentPublishTimes :many
+-- Returns when the feed's latest posts were published, newest first.
+SELECT published_at::timestamp AS published_at
+FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT $2;
+
+-- name: MarkPostsPruned :exec
+-- Takes the same arguments as PrunePosts, and must run right before it.
+INSERT INTO pruned_posts (url, feed_id, pruned_at)
+SELECT posts.url, posts.feed_id, sqlc.arg(pruned_at)::timestamp
+FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id)
+    AND NOT EXISTS (
+        SELECT 1
+        FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred = true
+    )
+    AND (posts.created_at < sqlc.narg(older_than) OR (
+        sqlc.arg(keep_newest)::integer > 0 AND posts.item_id <= COALESCE((
+            SELECT newest.item_id
+            FROM posts AS newest
+            WHERE newest.feed_id = sqlc.arg(feed_id)
+            ORDER BY newest.item_id DESC
+            LIMIT 1 OFFSET sqlc.arg(keep_newest)
+        ), 0)
+    ))
+ON CONFLICT (url) DO NOTHING;
+
+-- name: GetPrunedPostURLsForFeed :many
+SELECT url
+FROM pruned_posts
+WHERE feed_id = $1;
+
+-- name: DeletePrunedPost :exec
+-- The feed no longer lists the url, so it can't be stored again.
+DELETE FROM pruned_posts
+WHERE url = $1;
+
+-- name: PrunePosts :execrows
+-- Deletes the feed's posts stored before older_than, and the ones beyond
+-- its keep_newest latest (0 for no limit). Starred posts are kept.
+DELETE FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id)
+    AND NOT EXISTS (
+        SELECT 1
+        FROM post_states
+        WHERE post_states.post_id = posts.id AND post_states.starred = true
+    )
+    AND (posts.created_at < sqlc.narg(older_than) OR (
+        sqlc.arg(keep_newest)::integer > 0 AND posts.item_id <= COALESCE((
+            SELECT newest.item_id
+            FROM posts AS newest
+            WHERE newest.feed_id = sqlc.arg(feed_id)
+            ORDER BY newest.item_id DESC
+            LIMIT 1 OFFSET sqlc.arg(keep_newest)
+        ), 0)
+    ));
+
+-- name: GetPostDataCounts :one
+SELECT
+    (SELECT COUNT(*) FROM posts) AS posts,
+    (SELECT COUNT(*) FROM post_states) AS post_states,
+    (SELECT COUNT(*) FROM post_tags) AS post_tags,
+    (SELECT COUNT(*) FROM webhook_deliveries) AS webhook_deliveries,
+    (SELECT COUNT(*) FROM alert_events) AS alert_events;
+
+-- name: ResetPrunedPosts :exec
+DELETE FROM pruned_posts;
\ No newline at end of file
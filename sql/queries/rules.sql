@@ -0,0 +1,41 @@
+-- name: CreateRule :one
+INSERT INTO rules (id, created_at, updated_at, user_id, feed_id, title_pattern, description_pattern, author, category, action, tag)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
+)
+RETURNING *;
+
+-- name: GetRulesForUser :many
+SELECT rules.*, feeds.url AS feed_url
+FROM rules
+LEFT JOIN feeds
+    ON rules.feed_id = feeds.id
+WHERE rules.user_id = $1
+ORDER BY rules.created_at;
+
+-- name: GetRulesForFeed :many
+SELECT rules.*
+FROM rules
+INNER JOIN feed_follows
+    ON rules.user_id = feed_follows.user_id
+WHERE feed_follows.feed_id = sqlc.arg(feed_id)
+    AND (rules.feed_id IS NULL OR rules.feed_id = sqlc.arg(feed_id))
+ORDER BY rules.created_at;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteRulesForUser :exec
+DELETE FROM rules
+WHERE user_id = $1;
\ No newline at end of file
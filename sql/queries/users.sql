@@ -1,10 +1,11 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, is_admin)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5
 )
 RETURNING *;
 
@@ -17,5 +18,66 @@ WHERE name = $1;
 SELECT *
 FROM users;
 
+-- name: CountUsers :one
+SELECT COUNT(*)
+FROM users;
+
+-- name: CountAdmins :one
+SELECT COUNT(*)
+FROM users
+WHERE is_admin = true;
+
+-- name: SetUserAdmin :exec
+UPDATE users
+SET is_admin = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: RenameUser :exec
+-- API credentials are derived from the user name, so renaming a user
+-- clears them until a new password is set.
+UPDATE users
+SET name = $2, api_password_hash = NULL, fever_api_key = NULL, updated_at = $3
+WHERE id = $1;
+
+-- name: GetUserUsage :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = sqlc.arg(user_id)) AS follows,
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = sqlc.arg(user_id)) AS owned_feeds,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = sqlc.arg(user_id)) AS post_states,
+    (SELECT COUNT(*) FROM post_tags WHERE post_tags.user_id = sqlc.arg(user_id)) AS post_tags,
+    (SELECT COUNT(*) FROM webhooks WHERE webhooks.user_id = sqlc.arg(user_id)) AS webhooks,
+    (SELECT COUNT(*) FROM rules WHERE rules.user_id = sqlc.arg(user_id)) AS rules,
+    (SELECT COUNT(*) FROM alerts WHERE alerts.user_id = sqlc.arg(user_id)) AS alerts;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
+
 -- name: ResetUsers :exec
-DELETE FROM users;
\ No newline at end of file
+DELETE FROM users;
+
+-- name: SetUserAPIPassword :exec
+UPDATE users
+SET api_password_hash = $2, fever_api_key = $3, updated_at = $4
+WHERE id = $1;
+
+-- name: GetUserByFeverAPIKey :one
+SELECT *
+FROM users
+WHERE fever_api_key = $1;
+
+-- name: SetUserEmail :exec
+UPDATE users
+SET email = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: GetDigestRecipients :many
+SELECT *
+FROM users
+WHERE email IS NOT NULL
+ORDER BY name;
+
+-- name: MarkDigestSent :exec
+UPDATE users
+SET last_digest_at = $2, last_digest_item_id = $3
+WHERE id = $1;
\ No newline at end of file
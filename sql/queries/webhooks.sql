@@ -0,0 +1,95 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (id, created_at, updated_at, user_id, url, feed_id, secret)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetWebhooksForUser :many
+SELECT webhooks.*, feeds.url AS feed_url
+FROM webhooks
+LEFT JOIN feeds
+    ON webhooks.feed_id = feeds.id
+WHERE webhooks.user_id = $1
+ORDER BY webhooks.created_at;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetWebhooksForFeed :many
+SELECT webhooks.*
+FROM webhooks
+INNER JOIN feed_follows
+    ON webhooks.user_id = feed_follows.user_id
+WHERE feed_follows.feed_id = sqlc.arg(feed_id)
+    AND (webhooks.feed_id IS NULL OR webhooks.feed_id = sqlc.arg(feed_id));
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, created_at, updated_at, webhook_id, post_id, status, next_attempt_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    'pending',
+    $2
+);
+
+-- name: GetDueWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.attempts,
+    webhooks.url AS webhook_url, webhooks.secret,
+    posts.item_id, posts.title, posts.url AS post_url, posts.description, posts.published_at,
+    feeds.name AS feed_name, feeds.url AS feed_url
+FROM webhook_deliveries
+INNER JOIN webhooks
+    ON webhook_deliveries.webhook_id = webhooks.id
+INNER JOIN posts
+    ON webhook_deliveries.post_id = posts.id
+INNER JOIN feeds
+    ON posts.feed_id = feeds.id
+WHERE webhook_deliveries.status = 'pending' AND webhook_deliveries.next_attempt_at <= sqlc.arg(now)
+ORDER BY webhook_deliveries.next_attempt_at
+LIMIT sqlc.arg(max_deliveries);
+
+-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered',
+    attempts = attempts + 1,
+    last_status_code = sqlc.arg(last_status_code),
+    last_error = NULL,
+    updated_at = sqlc.arg(delivered_at),
+    delivered_at = sqlc.arg(delivered_at)
+WHERE id = sqlc.arg(id);
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    last_status_code = $3,
+    last_error = $4,
+    next_attempt_at = $5,
+    updated_at = $6
+WHERE id = $1;
+
+-- name: GetWebhookDeliveriesForUser :many
+SELECT webhook_deliveries.*, webhooks.url AS webhook_url, posts.title AS post_title
+FROM webhook_deliveries
+INNER JOIN webhooks
+    ON webhook_deliveries.webhook_id = webhooks.id
+INNER JOIN posts
+    ON webhook_deliveries.post_id = posts.id
+WHERE webhooks.user_id = $1
+ORDER BY webhook_deliveries.created_at DESC
+LIMIT $2;
+
+-- name: DeleteWebhooksForUser :exec
+DELETE FROM webhooks
+WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN item_id BIGSERIAL NOT NULL UNIQUE;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN item_id;
\ No newline at end of file
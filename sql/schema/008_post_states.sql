@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE post_states(
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id uuid NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    read BOOLEAN NOT NULL DEFAULT false,
+    starred BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE post_states;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN fever_api_key TEXT UNIQUE;
+
+ALTER TABLE feeds
+ADD COLUMN api_id BIGSERIAL NOT NULL UNIQUE;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN fever_api_key;
+
+ALTER TABLE feeds
+DROP COLUMN api_id;
\ No newline at end of file
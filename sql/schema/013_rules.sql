@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE rules(
+    id uuid PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feed_id uuid REFERENCES feeds(id) ON DELETE CASCADE,
+    title_pattern TEXT,
+    description_pattern TEXT,
+    author TEXT,
+    category TEXT,
+    action TEXT NOT NULL,
+    tag TEXT
+);
+
+CREATE TABLE post_tags(
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id uuid NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id, tag)
+);
+
+ALTER TABLE post_states
+ADD COLUMN muted BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE post_states
+DROP COLUMN muted;
+
+DROP TABLE post_tags;
+DROP TABLE rules;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE alerts(
+    id uuid PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pattern TEXT NOT NULL,
+    notifier TEXT NOT NULL,
+    target TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE alert_events(
+    id uuid PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    alert_id uuid NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    post_id uuid NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    error TEXT
+);
+
+-- +goose Down
+DROP TABLE alert_events;
+DROP TABLE alerts;
\ No newline at end of file
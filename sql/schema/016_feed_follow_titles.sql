@@ -0,0 +1,8 @@
+-- +goose Up
+-- A user's own name for a feed they follow; NULL uses feeds.name.
+ALTER TABLE feed_follows
+ADD COLUMN title TEXT;
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN title;
\ No newline at end of file
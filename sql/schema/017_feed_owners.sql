@@ -0,0 +1,24 @@
+-- +goose Up
+-- Feeds are shared by everyone following them, so deleting the user who
+-- added a feed leaves it without an owner instead of deleting it.
+ALTER TABLE feeds
+ALTER COLUMN user_id DROP NOT NULL;
+
+ALTER TABLE feeds
+DROP CONSTRAINT feeds_user_id_fkey;
+
+ALTER TABLE feeds
+ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+DELETE FROM feeds
+WHERE user_id IS NULL;
+
+ALTER TABLE feeds
+DROP CONSTRAINT feeds_user_id_fkey;
+
+ALTER TABLE feeds
+ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE feeds
+ALTER COLUMN user_id SET NOT NULL;
\ No newline at end of file
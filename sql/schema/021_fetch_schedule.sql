@@ -0,0 +1,20 @@
+-- +goose Up
+-- When the aggregator fetches each feed next. NULL is due right away.
+ALTER TABLE feeds
+ADD COLUMN next_fetch_at TIMESTAMP;
+
+-- Per-feed override of the refresh interval the feed asks for. NULL
+-- follows the feed.
+ALTER TABLE feeds
+ADD COLUMN refresh_interval_seconds BIGINT;
+
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at);
+
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+
+ALTER TABLE feeds
+DROP COLUMN refresh_interval_seconds;
+
+ALTER TABLE feeds
+DROP COLUMN next_fetch_at;
\ No newline at end of file
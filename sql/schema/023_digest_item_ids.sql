@@ -0,0 +1,9 @@
+-- +goose Up
+-- The last post each user's digest covered, for the next one to page on
+-- from, as item ids follow the order posts were stored in.
+ALTER TABLE users
+ADD COLUMN last_digest_item_id BIGINT;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN last_digest_item_id;
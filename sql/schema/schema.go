@@ -0,0 +1,23 @@
+// Package schema embeds the goose migrations so gator can apply them
+// itself with the migrate command.
+package schema
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var Postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// SQLite returns the migrations for the SQLite backend.
+func SQLite() fs.FS {
+	migrations, err := fs.Sub(sqlite, "sqlite")
+	if err != nil {
+		panic(err)
+	}
+	return migrations
+}
@@ -0,0 +1,146 @@
+-- +goose Up
+-- The SQLite schema starts at the state PostgreSQL migrations 001 to 018
+-- leave, so both backends share version numbers from here on. Feeds and
+-- posts use their API id as the rowid, since SQLite only generates
+-- sequence values for the primary key.
+CREATE TABLE users (
+    id TEXT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT UNIQUE NOT NULL,
+    api_password_hash TEXT,
+    fever_api_key TEXT UNIQUE,
+    email TEXT,
+    last_digest_at TIMESTAMP,
+    is_admin BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE TABLE feeds (
+    id TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    last_fetched_at TIMESTAMP,
+    api_id INTEGER PRIMARY KEY AUTOINCREMENT
+);
+
+CREATE TABLE feed_follows (
+    id TEXT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    title TEXT,
+    UNIQUE (user_id, feed_id)
+);
+
+CREATE TABLE feed_follow_tags(
+    feed_follow_id TEXT NOT NULL REFERENCES feed_follows(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (feed_follow_id, tag)
+);
+
+CREATE TABLE posts(
+    id TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    author TEXT NOT NULL DEFAULT '',
+    categories TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE post_states(
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    read BOOLEAN NOT NULL DEFAULT false,
+    starred BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP NOT NULL,
+    muted BOOLEAN NOT NULL DEFAULT false,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE webhooks(
+    id TEXT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    feed_id TEXT REFERENCES feeds(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL
+);
+
+CREATE TABLE webhook_deliveries(
+    id TEXT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_status_code INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMP
+);
+
+CREATE TABLE rules(
+    id TEXT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feed_id TEXT REFERENCES feeds(id) ON DELETE CASCADE,
+    title_pattern TEXT,
+    description_pattern TEXT,
+    author TEXT,
+    category TEXT,
+    action TEXT NOT NULL,
+    tag TEXT
+);
+
+CREATE TABLE post_tags(
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id, tag)
+);
+
+CREATE TABLE alerts(
+    id TEXT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pattern TEXT NOT NULL,
+    notifier TEXT NOT NULL,
+    target TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE alert_events(
+    id TEXT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    alert_id TEXT NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    error TEXT
+);
+
+-- +goose Down
+DROP TABLE alert_events;
+DROP TABLE alerts;
+DROP TABLE post_tags;
+DROP TABLE rules;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+DROP TABLE post_states;
+DROP TABLE posts;
+DROP TABLE feed_follow_tags;
+DROP TABLE feed_follows;
+DROP TABLE feeds;
+DROP TABLE users;
\ No newline at end of file
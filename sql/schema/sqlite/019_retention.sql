@@ -0,0 +1,29 @@
+-- +goose Up
+-- Per-feed overrides of the retention policy in the config file. NULL
+-- follows the config, 0 keeps posts regardless of that limit.
+ALTER TABLE feeds
+ADD COLUMN retention_max_age_seconds BIGINT;
+
+ALTER TABLE feeds
+ADD COLUMN retention_max_posts INTEGER;
+
+-- Urls of pruned posts, so the aggregator doesn't store them again while
+-- their feed still lists them.
+CREATE TABLE pruned_posts(
+    url TEXT PRIMARY KEY,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    pruned_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX posts_feed_id_item_id_idx ON posts (feed_id, item_id);
+
+-- +goose Down
+DROP INDEX posts_feed_id_item_id_idx;
+
+DROP TABLE pruned_posts;
+
+ALTER TABLE feeds
+DROP COLUMN retention_max_posts;
+
+ALTER TABLE feeds
+DROP COLUMN retention_max_age_seconds;
\ No newline at end of file
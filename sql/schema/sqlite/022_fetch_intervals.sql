@@ -0,0 +1,19 @@
+-- +goose Up
+-- The interval the aggregator last scheduled each feed with, and what set
+-- it, for the schedule command.
+ALTER TABLE feeds
+ADD COLUMN fetch_interval_seconds BIGINT;
+
+ALTER TABLE feeds
+ADD COLUMN fetch_interval_source TEXT;
+
+CREATE INDEX posts_feed_id_published_at_idx ON posts (feed_id, published_at);
+
+-- +goose Down
+DROP INDEX posts_feed_id_published_at_idx;
+
+ALTER TABLE feeds
+DROP COLUMN fetch_interval_source;
+
+ALTER TABLE feeds
+DROP COLUMN fetch_interval_seconds;
\ No newline at end of file